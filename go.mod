@@ -5,6 +5,9 @@ go 1.24.4
 require (
 	github.com/go-rod/rod v0.115.0
 	github.com/pdfcpu/pdfcpu v0.8.0
+	github.com/yuin/goldmark v1.8.2
+	golang.org/x/image v0.15.0
+	golang.org/x/net v0.38.0
 )
 
 require (
@@ -18,7 +21,6 @@ require (
 	github.com/ysmood/got v0.34.1 // indirect
 	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.8.0 // indirect
-	golang.org/x/image v0.15.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,1516 @@
+// Package build orchestrates the complete generation of the Go by Example
+// e-book.
+//
+// The pipeline downloads all examples, renders each one to its own PDF,
+// merges them, creates an introduction page with a Table of Contents and
+// finally adds navigation bookmarks. Every step returns errors up the stack
+// instead of terminating the process, so the whole build can be embedded in
+// other programs or exercised by integration tests.
+//
+// Example usage:
+//
+//	cfg := build.DefaultConfig()
+//	cfg.FinalPDF = "book.pdf"
+//	if err := build.Run(cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go-by-example-book/internal/category"
+	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/htmlpdf"
+	"go-by-example-book/internal/logging"
+	"go-by-example-book/internal/manifest"
+	"go-by-example-book/internal/naming"
+	"go-by-example-book/internal/pdfutil"
+	"go-by-example-book/internal/progress"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Config holds all options of a build
+type Config struct {
+	OutputDir      string            // Directory for per-example HTML files, assets and the manifest
+	PDFDir         string            // Directory for per-example PDFs; empty uses OutputDir
+	FinalPDF       string            // Path of the combined PDF
+	Concurrency    int               // Number of examples fetched in parallel
+	Threshold      float64           // Minimum word overlap for reusing an existing local HTML file
+	MinWords       int               // Minimum words of both filenames for the overlap to count; shorter names must match exactly
+	MatchMetric    naming.Metric     // How the overlap compared against Threshold is measured; empty means naming.MetricJaccard
+	Limit          int               // Only build the first Limit examples; 0 builds all
+	RequestDelay   time.Duration     // Minimum time between two upstream requests; 0 disables the delay
+	UserAgent      string            // User-Agent of all upstream requests; empty uses github.DefaultUserAgent
+	MinContentSize int               // Minimum size in bytes of an example's HTML; smaller ones are skipped
+	Include        string            // Regular expression an example filename must match; empty includes all
+	Exclude        string            // Regular expression that drops matching example filenames; wins over Include
+	Logger         *slog.Logger      // Logger for all output; nil uses the default logger
+	Context        context.Context   // Canceling it stops starting new examples, see ErrInterrupted; nil never cancels
+	Reporter       progress.Reporter // Progress reporter for the per-example loop; nil disables progress
+
+	// ListingURL and RawBaseURL locate the upstream example listing and raw
+	// files; see github.Options. Tests can point them at a local server.
+	ListingURL string
+	RawBaseURL string
+
+	// RepoDir builds from a local gobyexample clone instead of GitHub; no
+	// network access is needed
+	RepoDir string
+
+	// BrowserBinPath is the Chromium/Chrome executable to launch; empty lets
+	// Rod find or download a browser. Defaults to the ROD_BROWSER_PATH
+	// environment variable.
+	BrowserBinPath string
+
+	// NoSandbox starts the browser without Chromium's sandbox, which is
+	// required when running as root, e.g. in Docker; see htmlpdf.BrowserOptions
+	NoSandbox bool
+
+	InlineAssets    bool // Inline site.css and images into each example's HTML so it is self-contained
+	KeepInteractive bool // Keep the run/copy buttons and site.js instead of stripping them before rendering
+	NormalizeHTML   bool // Rewrite each example's HTML into well-formed markup before rendering, for stable pagination
+	SourceFooter    bool // Add a footer linking the example's page on gobyexample.com, for attribution
+	NumberExamples  bool // Add an "Example N" heading to every example, numbered like the bookmarks
+	ValidateHTML    bool // Reject example HTML that is not a page with a body before writing it, see htmlpdf.ValidateHTML
+
+	// ThemeCSS overrides the site styling of every example page, e.g. with
+	// htmlpdf.PrintThemeCSS. Empty keeps the site's own styling.
+	ThemeCSS string
+
+	// Scale is the print scale of the example pages, between
+	// htmlpdf.MinScale and htmlpdf.MaxScale; e.g. 0.9 fits wide code
+	Scale float64
+
+	// DeviceScale renders the example pages with this device pixel ratio,
+	// e.g. 2 for sharper images in print; 0 keeps the browser's ratio. See
+	// htmlpdf.PDFOptions.DeviceScale.
+	DeviceScale float64
+
+	// Layout arranges the explanation and code of every example page, e.g.
+	// htmlpdf.LayoutTwoColumn; empty keeps the site's single-column layout
+	Layout htmlpdf.Layout
+
+	// WaitSelector and WaitTimeout make rendering wait for an element of
+	// the example pages, e.g. the highlighted code; see htmlpdf.PDFOptions
+	WaitSelector string
+	WaitTimeout  time.Duration
+
+	// RenderDelay is a pause after every rendered example that gives the
+	// browser time to settle between pages; 0 disables it. See
+	// DefaultRenderDelay.
+	RenderDelay time.Duration
+
+	// Preface is an HTML fragment placed on its own pages between the
+	// introduction and the TOC, e.g. course information; empty for none
+	Preface string
+
+	// FontFiles are font files (TTF, OTF, WOFF, WOFF2) used for characters
+	// the site's fonts cannot display, e.g. CJK text; see htmlpdf.FontFaceCSS
+	FontFiles []string
+
+	// MarkdownFiles are the user's own lessons, as .md files or directories
+	// of them, added to the book like examples. MarkdownOrder places them;
+	// empty means MarkdownEnd, see DefaultConfig.
+	MarkdownFiles []string
+	MarkdownOrder MarkdownOrder
+
+	// Since, when set, keeps only the examples that changed at or after it,
+	// for a supplement of what is new; examples of unknown date are kept.
+	// See filterChangedSince.
+	Since time.Time
+
+	// Appendices are PDFs, e.g. a reference card, appended to the book (or
+	// to every booklet) with a bookmark each; see htmlpdf.AppendPDFs
+	Appendices []string
+
+	// Index adds an alphabetical index of the examples with their pages
+	// after the last example, and a bookmark for it; see
+	// htmlpdf.BuildIndexHTML. The appendices follow the index.
+	Index bool
+
+	// CombinedHTML, when set, writes all examples into this single HTML file
+	// instead of building the PDF. No browser is launched in this mode.
+	CombinedHTML string
+
+	// HTMLPageBreaks starts every example of the CombinedHTML document on a
+	// new page when it is printed
+	HTMLPageBreaks bool
+
+	// CodeDir, when set, writes the Go source of every example without the
+	// prose into this directory as <file>.go instead of building the PDF.
+	// No browser is launched in this mode.
+	CodeDir string
+
+	// Watermark is stamped on every page of the final PDF; an empty Text
+	// disables watermarking
+	Watermark htmlpdf.Watermark
+
+	Optimize bool // Deduplicate resources of the final PDF to reduce its size
+	// ExamplesOnly writes just the merged examples, without intro, TOC and
+	// bookmarks, e.g. to embed them in a larger document
+	ExamplesOnly bool
+
+	// NoMerge stops after rendering the per-example PDFs, e.g. for a docs
+	// site linking them one by one. No book is written, so the options
+	// finishing it, like the watermark or encryption, do not apply.
+	NoMerge bool
+
+	KeepTemp bool // Keep the temporary directory with the intermediate intro and merged files for debugging
+
+	// Validation controls how a final PDF that fails validation is treated
+	Validation ValidationMode
+
+	// GroupByCategory orders the book by example category, with a section
+	// per category in the TOC and nested bookmarks
+	GroupByCategory bool
+
+	// Duplex prepares the book for double-sided printing: blank pages are
+	// inserted so the first example and every example after it start on a
+	// right-hand (odd) page
+	Duplex bool
+
+	// Force re-downloads and re-renders every example, ignoring existing
+	// HTML and PDF files, e.g. after changing render options
+	Force bool
+
+	// RefreshAssets re-downloads site.css and the other assets, which are
+	// otherwise only downloaded when missing
+	RefreshAssets bool
+
+	// IntroOnly skips fetching and rendering and rebuilds the book from the
+	// per-example PDFs of a previous build, e.g. after changing the intro
+	// or the preface. Incompatible with CombinedHTML.
+	IntroOnly bool
+
+	// SplitByCategory writes one booklet per example category instead of a
+	// single book; see SplitPDFPath for the file names
+	SplitByCategory bool
+
+	// Encryption password-protects the final PDF; it is applied last and
+	// only when a password is set
+	Encryption htmlpdf.Encryption
+
+	// CostPerPage is the price of printing one page; when set, the
+	// estimated printing cost of the finished PDFs is logged
+	CostPerPage float64
+
+	// Date is recorded as the creation and modification date of the final
+	// PDF, whose file identifier is then derived from its content instead
+	// of the clock; see htmlpdf.StampDate. The zero value keeps the time of
+	// the build. Ignored for encrypted PDFs.
+	Date time.Time
+}
+
+// ValidationMode controls the validation of the final PDF
+type ValidationMode string
+
+const (
+	ValidationOff  ValidationMode = "off"  // Do not validate the final PDF
+	ValidationWarn ValidationMode = "warn" // Log a warning if the final PDF is invalid
+	ValidationFail ValidationMode = "fail" // Fail the build if the final PDF is invalid
+)
+
+// ErrInterrupted is returned by Run when cfg.Context was canceled; the book
+// was written with the examples that were finished by then, if any
+var ErrInterrupted = errors.New("build interrupted")
+
+// interrupted reports whether the build was asked to stop
+func interrupted(cfg Config) bool {
+	return cfg.Context != nil && cfg.Context.Err() != nil
+}
+
+// DefaultRenderDelay is the default pause after every rendered example
+const DefaultRenderDelay = 100 * time.Millisecond
+
+// BrowserPathEnv is the environment variable that provides the default BrowserBinPath
+const BrowserPathEnv = "ROD_BROWSER_PATH"
+
+// DefaultConfig returns the configuration used when nothing else is specified
+func DefaultConfig() Config {
+	defaults := github.DefaultOptions()
+	return Config{
+		OutputDir:      "files",
+		FinalPDF:       "go-by-example-generated-ebook.pdf",
+		Concurrency:    defaults.Concurrency,
+		Threshold:      defaults.Threshold,
+		MinWords:       defaults.MinWords,
+		MatchMetric:    naming.MetricJaccard,
+		RequestDelay:   defaults.RequestDelay,
+		UserAgent:      github.DefaultUserAgent,
+		MinContentSize: defaults.MinContentSize,
+		ListingURL:     defaults.ListingURL,
+		RawBaseURL:     defaults.RawBaseURL,
+
+		BrowserBinPath: os.Getenv(BrowserPathEnv),
+		Watermark:      htmlpdf.DefaultWatermark(),
+		Encryption:     htmlpdf.DefaultEncryption(),
+		Validation:     ValidationWarn,
+		Scale:          htmlpdf.DefaultScale,
+		Layout:         htmlpdf.LayoutSingleColumn,
+		HTMLPageBreaks: true,
+		WaitTimeout:    htmlpdf.DefaultWaitTimeout,
+		RenderDelay:    DefaultRenderDelay,
+		MarkdownOrder:  MarkdownEnd,
+	}
+}
+
+// renderResult holds the per-example PDFs that made it into the book
+//
+// The slices are kept in lockstep: index i of each refers to the same
+// example. Categories is nil unless the book is grouped by category, and
+// Numbers is nil unless the numbers are printed on the example pages.
+type renderResult struct {
+	Examples   []github.Example // Examples that produced a PDF
+	PDFPaths   []string         // Path of each example's PDF
+	PageCounts []int            // Page count of each example's PDF
+	Categories []string         // Category of each example, used for TOC sections and bookmark groups
+	Numbers    []int            // Number printed on each example's pages, used for the bookmarks
+}
+
+// add appends an example to the result, extending every slice at once
+//
+// number is recorded only if it is set; examples are numbered from 1, so
+// either all examples of a result have a number or none has.
+func (r *renderResult) add(ex github.Example, pdfPath string, pageCount, number int) {
+	r.Examples = append(r.Examples, ex)
+	r.PDFPaths = append(r.PDFPaths, pdfPath)
+	r.PageCounts = append(r.PageCounts, pageCount)
+	if number > 0 {
+		r.Numbers = append(r.Numbers, number)
+	}
+}
+
+// pick returns the examples at the given indexes, in that order
+//
+// Every slice of the result is picked, so the returned result stays in
+// lockstep like the one it is taken from.
+func (r renderResult) pick(indexes []int) renderResult {
+	var picked renderResult
+	for _, i := range indexes {
+		number := 0
+		if r.Numbers != nil {
+			number = r.Numbers[i]
+		}
+		picked.add(r.Examples[i], r.PDFPaths[i], r.PageCounts[i], number)
+		if r.Categories != nil {
+			picked.Categories = append(picked.Categories, r.Categories[i])
+		}
+	}
+	return picked
+}
+
+// validate reports an error if the slices of the result are not in lockstep
+//
+// A mismatch means a bug in how the result was built; assembling the book
+// from it would give examples the pages and bookmarks of their neighbours.
+func (r renderResult) validate() error {
+	n := len(r.Examples)
+	if len(r.PDFPaths) != n || len(r.PageCounts) != n ||
+		(r.Categories != nil && len(r.Categories) != n) || (r.Numbers != nil && len(r.Numbers) != n) {
+		return fmt.Errorf("internal error: %d examples but %d PDFs, %d page counts, %d categories and %d numbers",
+			n, len(r.PDFPaths), len(r.PageCounts), len(r.Categories), len(r.Numbers))
+	}
+	return nil
+}
+
+// prepOutputDir prepares the output directory for the PDF generation process
+//
+// This function creates the output directory if it doesn't exist and returns
+// the path to be used throughout the PDF generation process.
+//
+// Parameters:
+//   - outputDir: The directory for per-example files and assets
+//
+// Returns:
+//   - string: The path to the prepared output directory
+//   - error: Any error that occurred while creating the directory
+func prepOutputDir(outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create output directory: %v", err)
+	}
+	return outputDir, nil
+}
+
+// prepWorkDir creates the temporary directory for intermediate files
+//
+// Intermediate files never touch the output directory, so a crash cannot
+// leave them behind next to real content and parallel runs sharing an
+// output directory do not overwrite each other's files. site.css is copied
+// from outputDir so the intro page, which links it relatively, is styled.
+//
+// Parameters:
+//   - outputDir: The directory holding the downloaded assets
+//
+// Returns:
+//   - string: The path of the new temporary directory
+//   - error: Any error that occurred while creating the directory or
+//     copying site.css; the directory is removed again in that case
+func prepWorkDir(outputDir string) (string, error) {
+	workDir, err := os.MkdirTemp("", "go-by-example-book-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+
+	css, err := os.ReadFile(filepath.Join(outputDir, "site.css"))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(workDir, "site.css"), css, 0644)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(workDir)
+		return "", fmt.Errorf("failed to copy site.css to %s: %v", workDir, err)
+	}
+
+	return workDir, nil
+}
+
+// Run executes the complete book generation pipeline
+//
+// The steps are:
+// 1. Download the examples (reusing local files where possible)
+// 2. Render every example to its own PDF
+// 3. Merge the example PDFs
+// 4. Create the introduction page with the Table of Contents
+// 5. Merge the introduction with the examples and add bookmarks
+//
+// With cfg.IntroOnly, steps 1 and 2 are skipped and the per-example PDFs of
+// the previous build are used instead. With cfg.NoMerge, the build stops
+// after step 2.
+//
+// Failures of individual examples are logged and the example is left out of
+// the book; failures of the overall pipeline are returned as errors. When
+// the book was generated without some examples, a summary of their failures
+// is logged and an error wrapping ErrExamplesFailed is returned.
+//
+// Parameters:
+//   - cfg: The build configuration
+//
+// Returns:
+//   - error: Any error that prevented the book from being generated, or ErrExamplesFailed
+func Run(cfg Config) error {
+	return run(cfg, &Result{})
+}
+
+// RunWithResult executes the build like Run and also describes its outcome
+//
+// The result is returned even if the build fails, with everything that was
+// written up to the failure and the error message.
+//
+// Parameters:
+//   - cfg: The build configuration
+//
+// Returns:
+//   - *Result: The written outputs, the page range of every example and the failures
+//   - error: The same error Run would return
+//
+// Example:
+//
+//	result, err := build.RunWithResult(cfg)
+//	json.NewEncoder(os.Stdout).Encode(result)
+func RunWithResult(cfg Config) (*Result, error) {
+	result := &Result{Outputs: []Output{}}
+	err := run(cfg, result)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+// run implements Run, recording the outcome in result
+func run(cfg Config, result *Result) error {
+	// Failures are recorded even if the build ends early
+	failures := &failureLog{}
+	defer func() { result.Failures = failures.list() }()
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logging.Default()
+	}
+	github.SetLogger(logger)
+	htmlpdf.SetLogger(logger)
+	github.SetUserAgent(cfg.UserAgent)
+
+	reporter := cfg.Reporter
+	if reporter == nil {
+		reporter = progress.Nop{}
+	}
+
+	logger.Info("Starting Go by Example PDF generator with Rod + pdfcpu...")
+
+	// Check the font files before anything is downloaded
+	if _, err := htmlpdf.FontFaceCSS(cfg.FontFiles); err != nil {
+		return err
+	}
+
+	outputDir, err := prepOutputDir(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	if cfg.PDFDir == "" {
+		cfg.PDFDir = outputDir
+	} else if _, err := prepOutputDir(cfg.PDFDir); err != nil {
+		return err
+	}
+
+	include, err := compileFilter("include", cfg.Include)
+	if err != nil {
+		return err
+	}
+	exclude, err := compileFilter("exclude", cfg.Exclude)
+	if err != nil {
+		return err
+	}
+
+	var rendered renderResult
+	var examples []github.Example
+	if cfg.IntroOnly {
+		rendered, err = loadCachedExamples(logger, outputDir, cfg.PDFDir, include, exclude, cfg.Limit, failures)
+		if err != nil {
+			return err
+		}
+	} else {
+		examples, err = github.GetGitHubFiles(outputDir, github.Options{
+			Threshold:      cfg.Threshold,
+			MinWords:       cfg.MinWords,
+			Metric:         cfg.MatchMetric,
+			Concurrency:    cfg.Concurrency,
+			Limit:          cfg.Limit,
+			RequestDelay:   cfg.RequestDelay,
+			MinContentSize: cfg.MinContentSize,
+			Include:        include,
+			Exclude:        exclude,
+			ListingURL:     cfg.ListingURL,
+			RawBaseURL:     cfg.RawBaseURL,
+			RepoDir:        cfg.RepoDir,
+			Force:          cfg.Force,
+			RefreshAssets:  cfg.RefreshAssets,
+			Context:        cfg.Context,
+			OnFailure: func(filename string, err error) {
+				failures.add(filename, StageDownload, err)
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get examples: %w", err)
+		}
+		logger.Info(fmt.Sprintf("Found %d examples", len(examples)))
+		logSourceSummary(logger, examples)
+
+		if len(cfg.MarkdownFiles) > 0 {
+			lessons, err := loadMarkdownExamples(cfg.MarkdownFiles)
+			if err != nil {
+				return err
+			}
+			examples = addMarkdownExamples(examples, lessons, cfg.MarkdownOrder)
+			logger.Info(fmt.Sprintf("%d lessons, placed %s", len(lessons), cfg.MarkdownOrder), logging.Tag("MARKDOWN"))
+		}
+
+		if !cfg.Since.IsZero() {
+			examples = filterChangedSince(logger, outputDir, examples, cfg.Since)
+			if len(examples) == 0 {
+				return fmt.Errorf("no examples changed since %s", cfg.Since.Format(time.DateOnly))
+			}
+		}
+	}
+
+	// The fonts extend the font stacks of site.css and the theme, so they
+	// go last
+	if len(cfg.FontFiles) > 0 {
+		siteCSS, err := os.ReadFile(filepath.Join(outputDir, "site.css"))
+		if err != nil {
+			logger.Warn("Could not read site.css, its font stacks are not extended", "err", err)
+		}
+		fontCSS, err := htmlpdf.FontFaceCSS(cfg.FontFiles, string(siteCSS), cfg.ThemeCSS)
+		if err != nil {
+			return err
+		}
+		cfg.ThemeCSS += fontCSS
+	}
+
+	if cfg.CombinedHTML != "" {
+		if err := writeCombinedHTML(cfg, logger, outputDir, examples); err != nil {
+			return err
+		}
+		result.add(newHTMLOutput(cfg.CombinedHTML, examples))
+		return failures.report(logger)
+	}
+
+	if cfg.CodeDir != "" {
+		written, err := writeCodeFiles(cfg, logger, examples, failures)
+		if err != nil {
+			return err
+		}
+		result.add(newHTMLOutput(cfg.CodeDir, written))
+		return failures.report(logger)
+	}
+
+	browser, closeBrowser, err := htmlpdf.NewBrowserWithOptions(htmlpdf.BrowserOptions{BinPath: cfg.BrowserBinPath, NoSandbox: cfg.NoSandbox})
+	if err != nil {
+		return err
+	}
+	defer closeBrowser()
+
+	if !cfg.IntroOnly {
+		rendered = renderExamples(cfg, logger, reporter, browser, outputDir, examples, failures)
+	}
+	if interrupted(cfg) && len(rendered.Examples) == 0 {
+		return ErrInterrupted
+	}
+
+	if cfg.NoMerge {
+		for i, pdfPath := range rendered.PDFPaths {
+			result.add(newOutput(pdfPath, 0, renderResult{
+				Examples:   rendered.Examples[i : i+1],
+				PageCounts: rendered.PageCounts[i : i+1],
+			}))
+		}
+		logger.Info(fmt.Sprintf("%d example PDFs in %s/", len(rendered.PDFPaths), cfg.PDFDir), logging.Tag("SUCCESS"))
+		logPrintSummary(cfg, logger, result.Outputs)
+		if interrupted(cfg) {
+			failures.report(logger)
+			return fmt.Errorf("%w: only the first %d examples were rendered", ErrInterrupted, len(rendered.Examples))
+		}
+		return failures.report(logger)
+	}
+
+	workDir, err := prepWorkDir(outputDir)
+	if err != nil {
+		return err
+	}
+	succeeded := false
+	defer func() {
+		if succeeded && !cfg.KeepTemp {
+			os.RemoveAll(workDir)
+			return
+		}
+		logger.Info(workDir, logging.Tag("INTERMEDIATE FILES KEPT"))
+	}()
+
+	if cfg.SplitByCategory {
+		if err := assembleBooklets(cfg, logger, browser, workDir, rendered, result); err != nil {
+			return err
+		}
+	} else {
+		if cfg.GroupByCategory {
+			rendered = groupByCategory(rendered)
+		}
+		out, err := assembleOutput(cfg, logger, browser, workDir, cfg.FinalPDF, rendered)
+		if err != nil {
+			return err
+		}
+
+		if err := postProcess(cfg, logger, cfg.FinalPDF); err != nil {
+			return err
+		}
+		result.add(out)
+
+		logger.Info(cfg.FinalPDF, logging.Tag("COMBINED PDF CREATED"))
+	}
+
+	logger.Info("PDF generation completed!", logging.Tag("SUCCESS"))
+	logger.Info(fmt.Sprintf("Individual PDFs saved in: %s/", cfg.PDFDir))
+	if !cfg.SplitByCategory {
+		logger.Info(fmt.Sprintf("Combined PDF saved as: %s", cfg.FinalPDF))
+	}
+	if !cfg.ExamplesOnly {
+		logger.Info("Use the bookmarks panel in your PDF viewer for navigation!")
+	}
+	logPrintSummary(cfg, logger, result.Outputs)
+
+	succeeded = true
+	if interrupted(cfg) {
+		failures.report(logger)
+		return fmt.Errorf("%w: the book contains only the first %d examples", ErrInterrupted, len(rendered.Examples))
+	}
+	return failures.report(logger)
+}
+
+// postProcess applies the optional finishing steps to a finished book
+//
+// Returns:
+//   - error: Any error that occurred in one of the steps
+func postProcess(cfg Config, logger *slog.Logger, pdfPath string) error {
+	if err := htmlpdf.ApplyWatermark(pdfPath, cfg.Watermark); err != nil {
+		return err
+	}
+
+	if cfg.Optimize {
+		if _, _, err := htmlpdf.OptimizePDF(pdfPath); err != nil {
+			return err
+		}
+	}
+
+	// Every pdfcpu write records the current time, so this follows them all
+	if !cfg.Date.IsZero() && !cfg.Encryption.Enabled() {
+		if err := htmlpdf.StampDate(pdfPath, cfg.Date); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Validation != ValidationOff {
+		if err := htmlpdf.ValidatePDF(pdfPath); err != nil {
+			if cfg.Validation == ValidationFail {
+				return err
+			}
+			logger.Warn("Final PDF failed validation", "err", err)
+		}
+	}
+
+	// Encryption must come last, the other steps cannot read an encrypted file
+	if err := htmlpdf.EncryptPDF(pdfPath, cfg.Encryption); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// logPrintSummary logs the total page count of the finished PDFs and, if
+// cfg.CostPerPage is set, the estimated cost of printing them
+//
+// The pages are counted in the final files, after duplex padding and all
+// other post-processing. An output that cannot be read, e.g. because it is
+// encrypted, counts with the page total computed during assembly.
+func logPrintSummary(cfg Config, logger *slog.Logger, outputs []Output) {
+	total := 0
+	for _, out := range outputs {
+		pages, err := pdfutil.PageCount(out.Path)
+		if err != nil {
+			logger.Debug("Could not count pages, using the computed total", "file", out.Path, "err", err)
+			pages = out.TotalPages
+		}
+		total += pages
+	}
+
+	logger.Info(fmt.Sprintf("%d pages", total), logging.Tag("TOTAL"))
+	if cfg.CostPerPage > 0 {
+		logger.Info(fmt.Sprintf("%.2f (%d pages at %.2f per page)", float64(total)*cfg.CostPerPage, total, cfg.CostPerPage), logging.Tag("PRINT COST"))
+	}
+}
+
+// SplitPDFPath returns the path of the booklet for a category
+//
+// The category is appended to the base name of finalPDF, e.g. "book.pdf"
+// and "Files & System" give "book-files-system.pdf".
+//
+// Parameters:
+//   - finalPDF: The path of the combined PDF
+//   - categoryName: The category of the booklet
+//
+// Returns:
+//   - string: The path of the booklet
+func SplitPDFPath(finalPDF, categoryName string) string {
+	ext := filepath.Ext(finalPDF)
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(categoryName), "-"), "-")
+	return strings.TrimSuffix(finalPDF, ext) + "-" + slug + ext
+}
+
+// nonSlugChars matches the characters replaced when turning a category into a file name
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// groupByCategory reorders the rendered examples so that every category
+// forms one contiguous section, in the order of category.Names
+//
+// The order of the examples within a category is preserved.
+//
+// Returns:
+//   - renderResult: The reordered result with Categories filled in
+func groupByCategory(rendered renderResult) renderResult {
+	order, categories := categoryOrder(rendered.Examples)
+
+	rendered.Categories = categories
+	return rendered.pick(order)
+}
+
+// categoryOrder returns the order that groups examples by category, in the
+// order of category.Names, keeping the order within a category
+//
+// Returns:
+//   - []int: The indexes of the examples in grouped order
+//   - []string: The category of each example, indexed like examples
+func categoryOrder(examples []github.Example) ([]int, []string) {
+	rank := make(map[string]int)
+	for i, name := range category.Names() {
+		rank[name] = i
+	}
+
+	order := make([]int, len(examples))
+	categories := make([]string, len(examples))
+	for i, ex := range examples {
+		order[i] = i
+		categories[i] = category.Of(ex.Title)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rank[categories[order[a]]] < rank[categories[order[b]]]
+	})
+	return order, categories
+}
+
+// exampleNumbers numbers the examples the way they appear in the book
+//
+// The numbers follow the final order: the listing order, grouped by
+// category with cfg.GroupByCategory, and counted per booklet with
+// cfg.SplitByCategory. They are assigned before rendering, so an example
+// that fails leaves a gap instead of shifting the numbers printed on the
+// pages of the others.
+//
+// Returns:
+//   - []int: The number of each example, indexed like examples
+func exampleNumbers(cfg Config, examples []github.Example) []int {
+	numbers := make([]int, len(examples))
+	if !cfg.GroupByCategory && !cfg.SplitByCategory {
+		for i := range examples {
+			numbers[i] = i + 1
+		}
+		return numbers
+	}
+
+	order, categories := categoryOrder(examples)
+	perCategory := make(map[string]int)
+	for pos, i := range order {
+		if cfg.SplitByCategory {
+			perCategory[categories[i]]++
+			numbers[i] = perCategory[categories[i]]
+		} else {
+			numbers[i] = pos + 1
+		}
+	}
+	return numbers
+}
+
+// assembleBooklets builds one booklet per category from the rendered examples
+//
+// The per-example PDFs are reused as they are; only the merge, the intro
+// with its TOC and the bookmarks are produced per booklet. Categories
+// without examples are skipped.
+//
+// Returns:
+//   - error: Any error that prevented a booklet from being written
+func assembleBooklets(cfg Config, logger *slog.Logger, browser *rod.Browser, workDir string, rendered renderResult, result *Result) error {
+	groups := make(map[string][]int)
+	for i, ex := range rendered.Examples {
+		name := category.Of(ex.Title)
+		groups[name] = append(groups[name], i)
+	}
+
+	for _, name := range category.Names() {
+		if groups[name] == nil {
+			continue
+		}
+		group := rendered.pick(groups[name])
+
+		pdfPath := SplitPDFPath(cfg.FinalPDF, name)
+		logger.Info(fmt.Sprintf("%s (%d examples)", name, len(group.Examples)), logging.Tag("BOOKLET"))
+		out, err := assembleOutput(cfg, logger, browser, workDir, pdfPath, group)
+		if err != nil {
+			return fmt.Errorf("booklet %s: %v", name, err)
+		}
+		if err := postProcess(cfg, logger, pdfPath); err != nil {
+			return fmt.Errorf("booklet %s: %v", name, err)
+		}
+		result.add(out)
+		logger.Info(pdfPath, logging.Tag("BOOKLET CREATED"))
+	}
+
+	return nil
+}
+
+// logSourceSummary logs how many examples were fetched versus reused
+func logSourceSummary(logger *slog.Logger, examples []github.Example) {
+	counts := make(map[github.Source]int)
+	for _, ex := range examples {
+		counts[ex.Source]++
+	}
+	logger.Info(fmt.Sprintf("%d downloaded, %d cached (not modified), %d local matches, %d from local repository",
+		counts[github.Downloaded], counts[github.Cached], counts[github.LocalMatch], counts[github.LocalRepo]), logging.Tag("SOURCES"))
+}
+
+// compileFilter compiles an example filter pattern; an empty pattern
+// disables the filter and yields nil
+func compileFilter(name, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern: %v", name, err)
+	}
+	return re, nil
+}
+
+// writeCombinedHTML exports all examples as one HTML document
+//
+// The examples get the same preprocessing as for the PDF, except that assets
+// are always inlined once for the whole document rather than per example.
+//
+// Returns:
+//   - error: Any error that occurred while writing the document
+func writeCombinedHTML(cfg Config, logger *slog.Logger, outputDir string, examples []github.Example) error {
+	prepared := make([]github.Example, len(examples))
+	for i, ex := range examples {
+		if !cfg.KeepInteractive {
+			ex.Content = htmlpdf.StripInteractiveElements(ex.Content)
+		}
+		prepared[i] = ex
+	}
+
+	err := htmlpdf.WriteCombinedHTML(htmlpdf.CombinedHTMLParams{
+		Examples:   prepared,
+		AssetDir:   outputDir,
+		OutputPath: cfg.CombinedHTML,
+		ThemeCSS:   cfg.ThemeCSS,
+		PageBreaks: cfg.HTMLPageBreaks,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(cfg.CombinedHTML, logging.Tag("COMBINED HTML CREATED"))
+	logger.Info("HTML generation completed!", logging.Tag("SUCCESS"))
+	return nil
+}
+
+// writeCodeFiles exports the Go source of every example as a .go file
+//
+// Examples whose code cannot be extracted are recorded as failures and
+// skipped.
+//
+// Returns:
+//   - []github.Example: The examples a file was written for
+//   - error: Any error that occurred while creating the directory
+func writeCodeFiles(cfg Config, logger *slog.Logger, examples []github.Example, failures *failureLog) ([]github.Example, error) {
+	codeDir, err := prepOutputDir(cfg.CodeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	written := make([]github.Example, 0, len(examples))
+	for _, ex := range examples {
+		// Lessons are prose, not a Go program
+		if ex.Source == github.Markdown {
+			continue
+		}
+		code, err := htmlpdf.ExtractCode(ex.Content)
+		if err == nil && code == "" {
+			err = fmt.Errorf("no code blocks found")
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(codeDir, ex.File+".go"), []byte(code), 0644)
+		}
+		if err != nil {
+			logger.Error("Could not export code", "example", ex.Title, "err", err)
+			failures.add(ex.File, StageHTML, err)
+			continue
+		}
+		written = append(written, ex)
+	}
+
+	logger.Info(fmt.Sprintf("%d files in %s", len(written), codeDir), logging.Tag("CODE EXPORTED"))
+	return written, nil
+}
+
+// prepareHTML applies the configured preprocessing to an example's HTML
+//
+// Every step must be idempotent: content read back from a previously written
+// HTML file passes through here again, and must hash identically so the
+// manifest does not report it as changed.
+//
+// Returns:
+//   - string: The HTML content to write and render
+//   - error: Any error that occurred during preprocessing
+func prepareHTML(cfg Config, outputDir string, ex github.Example, number int) (string, error) {
+	content := ex.Content
+
+	if !cfg.KeepInteractive {
+		content = htmlpdf.StripInteractiveElements(content)
+	}
+
+	if cfg.InlineAssets {
+		inlined, err := htmlpdf.InlineAssets(content, outputDir)
+		if err != nil {
+			return "", err
+		}
+		content = inlined
+	}
+
+	if cfg.NormalizeHTML {
+		normalized, err := htmlpdf.NormalizeHTML(content)
+		if err != nil {
+			return "", err
+		}
+		content = normalized
+	}
+
+	// After normalizing, which would move whitespace around the footer; the
+	// Markdown lessons have no page on the site
+	if cfg.SourceFooter && ex.Source != github.Markdown {
+		content = htmlpdf.AddSourceFooter(content, exampleSlug(ex))
+	}
+	if cfg.NumberExamples {
+		content = htmlpdf.AddExampleNumber(content, number)
+	}
+
+	return content, nil
+}
+
+// exampleSlug returns the name of an example on gobyexample.com, which is
+// its upstream filename, e.g. "worker-pools"
+func exampleSlug(ex github.Example) string {
+	if ex.SourceURL != "" {
+		return path.Base(ex.SourceURL)
+	}
+	return strings.ReplaceAll(ex.File, "_", "-")
+}
+
+// renderExamples generates the individual PDF for every example
+//
+// The HTML files are written to outputDir and the PDFs to cfg.PDFDir.
+// Examples whose HTML and PDF already exist are skipped unless the build
+// manifest shows that their content changed or cfg.Force is set. Examples
+// that fail to render are logged, recorded in failures and left out of the
+// result.
+//
+// Returns:
+//   - renderResult: The examples that produced a PDF, with paths and page counts
+func renderExamples(cfg Config, logger *slog.Logger, reporter progress.Reporter, browser *rod.Browser, outputDir string, examples []github.Example, failures *failureLog) renderResult {
+	// Load the manifest of the previous build to detect changed examples
+	manifestPath := filepath.Join(outputDir, manifest.FileName)
+	buildManifest, err := manifest.Load(manifestPath)
+	if err != nil {
+		logger.Warn("Could not load manifest, starting a new one", "err", err)
+		buildManifest = manifest.New()
+	}
+
+	// Number the examples up front so failures do not shift the numbers
+	var numbers []int
+	if cfg.NumberExamples {
+		numbers = exampleNumbers(cfg, examples)
+	}
+
+	// Generate individual PDFs first (without TOC)
+	var rendered renderResult
+
+	// Reuse a single page for all conversions instead of opening one per example
+	page, err := openPage(browser)
+	if err != nil {
+		logger.Warn("Could not open a shared browser page, opening one per example", "err", err)
+		page = nil
+	} else {
+		defer page.Close()
+	}
+	var renderTime time.Duration
+	renderedCount := 0
+
+	// Report per-example progress on a single line instead of one log line per step
+	reporter.Start(len(examples))
+
+	// Generate individual example PDFs
+	for i, ex := range examples {
+		// The conversion in flight when interrupted is finished, no new one started
+		if interrupted(cfg) {
+			logger.Warn("Interrupted, not rendering the remaining examples", "remaining", len(examples)-i)
+			break
+		}
+
+		fileStatus := htmlpdf.ReceiveOutputFileStatus(outputDir, cfg.PDFDir, ex.File)
+
+		var number int
+		if numbers != nil {
+			number = numbers[i]
+		}
+		content, err := prepareHTML(cfg, outputDir, ex, number)
+		if err != nil {
+			logger.Error("Could not prepare HTML", "example", ex.Title, "err", err)
+			failures.add(ex.File, StageHTML, err)
+			reporter.Step(fmt.Sprintf("%s (failed)", ex.Title))
+			continue
+		}
+
+		// Regenerate examples whose content changed since the recorded build
+		status := "created"
+		contentHash := manifest.HashContent(content)
+		modified := recordedLastModified(buildManifest, ex, contentHash)
+		if _, recorded := buildManifest.Get(ex.File); recorded && buildManifest.Changed(ex.File, contentHash) {
+			status = "changed, regenerated"
+			fileStatus.HTMLExists = false
+			fileStatus.PDFExists = false
+		}
+		if cfg.Force {
+			status = "forced, regenerated"
+			fileStatus.HTMLExists = false
+			fileStatus.PDFExists = false
+		}
+
+		// If both files exist, reuse them; otherwise create what is missing
+		reused := fileStatus.HTMLExists && fileStatus.PDFExists
+		if !reused {
+			// Save original HTML content (only if HTML doesn't exist)
+			if !fileStatus.HTMLExists {
+				if cfg.ValidateHTML {
+					if err := htmlpdf.ValidateHTML(content); err != nil {
+						logger.Error("Invalid HTML", "example", ex.Title, "err", err)
+						failures.add(ex.File, StageHTML, err)
+						reporter.Step(fmt.Sprintf("%s (failed)", ex.Title))
+						continue
+					}
+				}
+				err = htmlpdf.CreateHTMLFile(content, fileStatus.HTMLPath)
+				if err != nil {
+					logger.Error("Could not create HTML", "example", ex.Title, "err", err)
+					failures.add(ex.File, StageHTML, err)
+					reporter.Step(fmt.Sprintf("%s (failed)", ex.Title))
+					continue
+				}
+			}
+
+			// Convert to PDF (only if PDF doesn't exist)
+			if !fileStatus.PDFExists {
+				renderStart := time.Now()
+				pdfOpts := htmlpdf.PDFOptions{
+					ThemeCSS:     cfg.ThemeCSS,
+					Scale:        cfg.Scale,
+					DeviceScale:  cfg.DeviceScale,
+					Layout:       cfg.Layout,
+					WaitSelector: cfg.WaitSelector,
+					WaitTimeout:  cfg.WaitTimeout,
+				}
+				err = renderPDF(browser, page, fileStatus.HTMLPath, fileStatus.PDFPath, pdfOpts)
+				if err != nil {
+					logger.Error("Could not create PDF", "example", ex.Title, "err", err)
+					failures.add(ex.File, StageRender, err)
+					reporter.Step(fmt.Sprintf("%s (failed)", ex.Title))
+					continue
+				}
+				renderTime += time.Since(renderStart)
+				renderedCount++
+
+				// Keep the per-example PDF recognizable when used on its own
+				if err := htmlpdf.SetPDFTitle(fileStatus.PDFPath, htmlpdf.DocumentTitle(content, ex.Title)); err != nil {
+					logger.Warn("Could not set PDF title", "example", ex.Title, "err", err)
+				}
+			} else {
+				status = "exists"
+			}
+		}
+
+		// From here on the example is in the book: it is added to all slices
+		// of the result at once, so a failure above cannot shift the page
+		// ranges of the examples after it
+		pageCount, err := pdfutil.PageCount(fileStatus.PDFPath)
+		if err != nil {
+			logger.Warn("Could not get page count", "example", ex.Title, "err", err)
+			failures.add(ex.File, StagePageCount, err)
+			pageCount = 1 // fallback assumption
+		}
+		rendered.add(ex, fileStatus.PDFPath, pageCount, number)
+		buildManifest.Set(ex.File, manifest.Entry{
+			SourceURL:    ex.SourceURL,
+			ContentHash:  contentHash,
+			PageCount:    pageCount,
+			LastModified: modified,
+		})
+		if reused {
+			reporter.Step(fmt.Sprintf("%s (skipped, files already exist)", ex.Title))
+			continue
+		}
+		reporter.Step(fmt.Sprintf("%s.pdf %s (%d pages)", ex.File, status, pageCount))
+
+		// Small delay to be nice to the browser
+		if cfg.RenderDelay > 0 {
+			time.Sleep(cfg.RenderDelay)
+		}
+	}
+	reporter.Done()
+
+	// Report render timing so the cost per example can be compared between runs
+	if renderedCount > 0 {
+		logger.Info(fmt.Sprintf("Rendered %d examples in %s (%s per example)",
+			renderedCount, renderTime.Round(time.Millisecond), (renderTime / time.Duration(renderedCount)).Round(time.Millisecond)))
+	}
+
+	// Record what was generated so the next run only regenerates changed examples
+	if err := buildManifest.Save(manifestPath); err != nil {
+		logger.Warn("Could not save manifest", "err", err)
+	}
+
+	return rendered
+}
+
+// maxIntroPasses bounds how often the intro is rendered while its page
+// count changes
+const maxIntroPasses = 5
+
+// mergeExamples merges the per-example PDFs into one PDF without intro,
+// TOC or bookmarks
+//
+// Returns:
+//   - error: Any error that occurred while merging
+func mergeExamples(logger *slog.Logger, pdfPaths []string, mergedPdf string) error {
+	if err := htmlpdf.MergePDFs(pdfPaths, mergedPdf); err != nil {
+		return fmt.Errorf("could not merge example PDFs: %v", err)
+	}
+	logger.Info(mergedPdf, logging.Tag("EXAMPLES MERGED"))
+	return nil
+}
+
+// renderPDF converts one example's HTML to PDF on page, or on a new page
+// of browser if page is nil
+//
+// Rod panics on some unexpected browser states. The panic is returned as
+// an error, so it costs only this example instead of the whole build.
+//
+// Returns:
+//   - error: Any error or panic that occurred during the conversion
+func renderPDF(browser *rod.Browser, page *rod.Page, htmlPath, pdfPath string, opts htmlpdf.PDFOptions) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("rendering panicked: %v", r)
+		}
+	}()
+
+	return htmlToPDF(browser, page, htmlPath, pdfPath, opts)
+}
+
+// openPage opens the browser page renderExamples shares between the
+// conversions; a variable so tests can render without a browser
+var openPage = func(browser *rod.Browser) (*rod.Page, error) {
+	return browser.Page(proto.TargetCreateTarget{})
+}
+
+// htmlToPDF converts one example's HTML to PDF for renderPDF; a variable so
+// tests can render without a browser
+var htmlToPDF = func(browser *rod.Browser, page *rod.Page, htmlPath, pdfPath string, opts htmlpdf.PDFOptions) error {
+	if page != nil {
+		return htmlpdf.HTMLToPDFOnPageWithOptions(page, htmlPath, pdfPath, opts)
+	}
+	return htmlpdf.HTMLToPDFWithOptions(browser, htmlPath, pdfPath, opts)
+}
+
+// assembleOutput writes a finished book (or booklet) to pdfPath
+//
+// Normally this is the full book with intro, TOC and bookmarks; with
+// cfg.ExamplesOnly only the merged examples are written.
+//
+// Returns:
+//   - Output: The written PDF with the page range of every example
+//   - error: Any error that prevented the PDF from being written
+func assembleOutput(cfg Config, logger *slog.Logger, browser *rod.Browser, workDir, pdfPath string, rendered renderResult) (Output, error) {
+	if err := rendered.validate(); err != nil {
+		return Output{}, err
+	}
+	if cfg.Duplex {
+		var err error
+		rendered, err = padForDuplex(workDir, rendered)
+		if err != nil {
+			return Output{}, err
+		}
+	}
+	if cfg.ExamplesOnly {
+		if err := mergeExamples(logger, rendered.PDFPaths, pdfPath); err != nil {
+			return Output{}, err
+		}
+		if err := appendAppendices(cfg, pdfPath); err != nil {
+			return Output{}, err
+		}
+		return newOutput(pdfPath, 0, rendered), nil
+	}
+	introPages, indexPages, err := assembleBook(cfg, logger, browser, workDir, pdfPath, rendered)
+	if err != nil {
+		return Output{}, err
+	}
+	if err := appendAppendices(cfg, pdfPath); err != nil {
+		return Output{}, err
+	}
+	out := newOutput(pdfPath, introPages, rendered)
+	out.TotalPages += indexPages
+	return out, nil
+}
+
+// appendAppendices appends cfg.Appendices to the PDF at pdfPath, if any
+//
+// Returns:
+//   - error: Any error that occurred while appending
+func appendAppendices(cfg Config, pdfPath string) error {
+	if len(cfg.Appendices) == 0 {
+		return nil
+	}
+	return htmlpdf.AppendPDFs(htmlpdf.AppendPDFsParams{
+		BookPDF:    pdfPath,
+		Appendices: cfg.Appendices,
+		OutputPDF:  pdfPath,
+	})
+}
+
+// padForDuplex pads every example PDF to an even page count
+//
+// Padded copies are written to workDir, so the per-example PDFs in the
+// output directory stay untouched. The page counts are updated, which keeps
+// the TOC page numbers and bookmarks in line with the inserted blank pages.
+//
+// Returns:
+//   - renderResult: The examples with the paths and page counts of the padded PDFs
+//   - error: Any error that occurred while padding
+func padForDuplex(workDir string, rendered renderResult) (renderResult, error) {
+	padded := rendered
+	padded.PDFPaths = make([]string, len(rendered.PDFPaths))
+	padded.PageCounts = make([]int, len(rendered.PageCounts))
+	for i, pdfPath := range rendered.PDFPaths {
+		outPath := filepath.Join(workDir, "duplex_"+filepath.Base(pdfPath))
+		path, pages, err := htmlpdf.PadToEvenPages(pdfPath, outPath, rendered.PageCounts[i])
+		if err != nil {
+			return renderResult{}, err
+		}
+		padded.PDFPaths[i] = path
+		padded.PageCounts[i] = pages
+	}
+	return padded, nil
+}
+
+// evenPages rounds a page count up to the next even number
+func evenPages(pages int) int {
+	return pages + pages%2
+}
+
+// renderIntro renders the intro page with the TOC and measures its length
+//
+// The HTML and PDF are written to workDir as <name>.html and <name>.pdf.
+//
+// Parameters:
+//   - browser: The Rod browser used for the conversion
+//   - workDir: The directory for the intro files
+//   - name: The base name of the intro files
+//   - preface: The user's preface HTML placed before the TOC; empty for none
+//   - examples: The examples listed in the TOC
+//   - startPage: The page number of the first example
+//   - examplePageCounts: The page count of each example; nil numbers the examples consecutively
+//   - categories: The category of each example for TOC sections; nil for a flat TOC
+//
+// Returns:
+//   - int: The page count of the rendered intro PDF
+//   - error: Any error that occurred while rendering or measuring the intro
+func renderIntro(browser *rod.Browser, workDir, name, preface string, examples []github.Example, startPage int, examplePageCounts []int, categories []string) (int, error) {
+	introHTML := htmlpdf.BuildIntroHTML(examples, startPage, examplePageCounts, htmlpdf.IntroOptions{
+		Preface:    preface,
+		Categories: categories,
+	})
+
+	pdfPath := filepath.Join(workDir, name+".pdf")
+	err := htmlpdf.WriteHTMLAndPDFExp(htmlpdf.HTMLToPDFParams{
+		HTMLContent: introHTML,
+		HTMLPath:    filepath.Join(workDir, name+".html"),
+		PDFPath:     pdfPath,
+		Browser:     browser,
+		Description: name,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	pageCount, err := pdfutil.PageCount(pdfPath)
+	if err != nil {
+		return 0, fmt.Errorf("could not count pages of %s: %v", pdfPath, err)
+	}
+	return pageCount, nil
+}
+
+// renderIndex renders the alphabetical index of the examples to
+// workDir/index.pdf
+//
+// Parameters:
+//   - browser: The Rod browser used for the conversion
+//   - workDir: The directory for the index files; it must contain site.css
+//   - examples: The examples in book order
+//   - firstPage: The page number of the first example in the book
+//   - pageCounts: The page count of each example, indexed like examples
+//
+// Returns:
+//   - string: The path of the index PDF
+//   - int: The page count of the index
+//   - error: Any error that occurred while rendering or counting the pages
+func renderIndex(browser *rod.Browser, workDir string, examples []github.Example, firstPage int, pageCounts []int) (string, int, error) {
+	pdfPath := filepath.Join(workDir, "index.pdf")
+	err := htmlpdf.WriteHTMLAndPDFExp(htmlpdf.HTMLToPDFParams{
+		HTMLContent: htmlpdf.BuildIndexHTML(examples, firstPage, pageCounts),
+		HTMLPath:    filepath.Join(workDir, "index.html"),
+		PDFPath:     pdfPath,
+		Browser:     browser,
+		Description: "index",
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	pageCount, err := pdfutil.PageCount(pdfPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not count pages of %s: %v", pdfPath, err)
+	}
+	return pdfPath, pageCount, nil
+}
+
+// layoutIntro renders the intro until the page count its TOC was numbered
+// with is the one it actually has
+//
+// The TOC's page numbers depend on the intro's length, and the intro's
+// length on the page numbers: longer numbers can wrap lines and push the
+// TOC onto another page. Every pass renders the final intro, numbered for
+// the page count the previous pass measured, so the rendered TOC is the one
+// that goes into the book; there is no estimate from placeholder numbers.
+// The first pass assumes a single page.
+//
+// With duplex set, the examples start after the intro padded to an even
+// page count, so only that padded count has to match.
+//
+// Parameters:
+//   - logger: The logger for the passes
+//   - duplex: Whether the intro will be padded to an even page count
+//   - render: Renders the intro with the first example on startPage and
+//     returns the intro's page count
+//
+// Returns:
+//   - int: The page count of the last rendered intro, whose TOC is correct
+//   - error: A render error, or an error if the page count did not settle
+//     within maxIntroPasses; the TOC would point to the wrong pages
+func layoutIntro(logger *slog.Logger, duplex bool, render func(startPage int) (int, error)) (int, error) {
+	assumedPages := 1
+	for pass := 1; pass <= maxIntroPasses; pass++ {
+		startPage := assumedPages + 1
+		if duplex {
+			startPage = evenPages(assumedPages) + 1
+		}
+		pages, err := render(startPage)
+		if err != nil {
+			return 0, err
+		}
+		if pages == assumedPages || (duplex && evenPages(pages) == evenPages(assumedPages)) {
+			return pages, nil
+		}
+		logger.Debug("Intro page count changed, re-rendering", "assumed", assumedPages, "actual", pages)
+		assumedPages = pages
+	}
+	return 0, fmt.Errorf("intro page count did not settle after %d passes, the TOC page numbers would be off", maxIntroPasses)
+}
+
+// assembleBook merges the rendered examples with the intro and adds bookmarks
+//
+// The introduction is always rendered with the real page numbers, as often
+// as it takes for the page count the TOC assumes to match the measured one;
+// see layoutIntro. The measured page count of that final intro is used for
+// merging and bookmarks.
+//
+// All intermediate files (intro.*, merged_examples.pdf and
+// temp_with_intro.pdf) are written to workDir, which must contain site.css
+// for the intro to be styled; see prepWorkDir.
+//
+// A preface from cfg.Preface is part of the intro, so its pages are
+// included in the measured intro page count.
+//
+// With cfg.Duplex set, the intro is padded to an even page count so the
+// first example starts on a right-hand page; the examples must already be
+// padded, see padForDuplex.
+//
+// With cfg.Index set, the alphabetical index is rendered into workDir and
+// placed after the examples, see renderIndex.
+//
+// Returns:
+//   - int: The number of pages before the first example
+//   - int: The number of pages of the index after the examples; 0 without one
+//   - error: Any error that prevented the final PDF from being written
+func assembleBook(cfg Config, logger *slog.Logger, browser *rod.Browser, workDir, finalPdf string, rendered renderResult) (int, int, error) {
+	// Only examples that produced a PDF go into the TOC and bookmarks
+	examples := rendered.Examples
+	examplePageCounts := rendered.PageCounts
+
+	// Merge all example PDFs into one (without TOC)
+	mergedExamplesPdf := filepath.Join(workDir, "merged_examples.pdf")
+	if err := mergeExamples(logger, rendered.PDFPaths, mergedExamplesPdf); err != nil {
+		return 0, 0, err
+	}
+
+	// Use pdfcpu to merge PDFs
+	conf := model.NewDefaultConfiguration()
+
+	// Create intro page with TOC and instructions
+	logger.Info("Creating intro page...")
+
+	introPageCount, err := layoutIntro(logger, cfg.Duplex, func(startPage int) (int, error) {
+		return renderIntro(browser, workDir, "intro", cfg.Preface, examples, startPage, examplePageCounts, rendered.Categories)
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not create intro: %v", err)
+	}
+	logger.Info(fmt.Sprintf("%d pages", introPageCount), logging.Tag("INTRO PAGE COUNT"))
+	logger.Info("intro.pdf", logging.Tag("INTRO PDF CREATED"))
+
+	introPdf := filepath.Join(workDir, "intro.pdf")
+	if cfg.Duplex {
+		introPdf, introPageCount, err = htmlpdf.PadToEvenPages(introPdf, filepath.Join(workDir, "duplex_intro.pdf"), introPageCount)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Now merge intro with examples
+	tempMergedPdf := filepath.Join(workDir, "temp_with_intro.pdf")
+	bookParts := []string{introPdf, mergedExamplesPdf}
+
+	// The index follows the examples; their pages are final once the intro is
+	var indexPageCount, indexStartPage int
+	if cfg.Index {
+		indexStartPage = introPageCount + 1
+		for _, pages := range examplePageCounts {
+			indexStartPage += pages
+		}
+		indexPdf, pages, err := renderIndex(browser, workDir, examples, introPageCount+1, examplePageCounts)
+		if err != nil {
+			return 0, 0, fmt.Errorf("could not create index: %v", err)
+		}
+		logger.Info(fmt.Sprintf("%d pages", pages), logging.Tag("INDEX CREATED"))
+		bookParts = append(bookParts, indexPdf)
+		indexPageCount = pages
+	}
+
+	err = api.MergeCreateFile(bookParts, tempMergedPdf, false, conf)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not merge intro with examples: %v", err)
+	}
+
+	// Point the TOC and index page links at the pages of the book
+	if _, err := htmlpdf.ResolvePageLinks(tempMergedPdf, introPageCount); err != nil {
+		logger.Warn("Could not resolve the TOC links, they may not work", "err", err)
+	}
+	if indexPageCount > 0 {
+		if _, err := htmlpdf.ResolvePageLinksRange(tempMergedPdf, indexStartPage, indexStartPage+indexPageCount-1); err != nil {
+			logger.Warn("Could not resolve the index links, they may not work", "err", err)
+		}
+	}
+
+	// Add bookmarks to the final PDF
+	err = htmlpdf.ApplyBookmarks(htmlpdf.ApplyBookmarksParams{
+		TempMergedPDF:     tempMergedPdf,
+		FinalPDF:          finalPdf,
+		Examples:          examples,
+		IntroPageCount:    introPageCount,
+		ExamplePageCounts: examplePageCounts,
+		Categories:        rendered.Categories,
+		Numbers:           rendered.Numbers,
+		IndexPageCount:    indexPageCount,
+		ShowBookmarks:     true,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not apply bookmarks: %v", err)
+	}
+
+	return introPageCount, indexPageCount, nil
+}
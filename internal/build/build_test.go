@@ -0,0 +1,437 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/htmlpdf"
+	"go-by-example-book/internal/logging"
+	"go-by-example-book/internal/progress"
+
+	"github.com/go-rod/rod"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// discardLogger drops all log output of the code under test
+var discardLogger = logging.New(io.Discard, slog.LevelError)
+
+// tocLinkPattern matches the page link of a TOC entry
+var tocLinkPattern = regexp.MustCompile(`<li><span class="page-number"><a href="#page=(\d+)">`)
+
+// tagPattern matches an HTML tag
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// paginate estimates the page count of an intro like a browser would: every
+// TOC entry takes a line per 50 characters of text, so longer page numbers
+// can wrap an entry and push the TOC onto another page
+func paginate(introHTML string) int {
+	const headerLines, linesPerPage = 20, 40
+	lines := headerLines
+	for _, line := range strings.Split(introHTML, "\n") {
+		if strings.Contains(line, "<li>") {
+			text := tagPattern.ReplaceAllString(line, "")
+			lines += 1 + len(strings.TrimSpace(text))/50
+		}
+	}
+	return (lines + linesPerPage - 1) / linesPerPage
+}
+
+func TestLayoutIntroManyEntries(t *testing.T) {
+	const entries = 200
+	examples := make([]github.Example, entries)
+	pageCounts := make([]int, entries)
+	for i := range examples {
+		// Titles just short enough to fit on a line with a two-digit page number
+		examples[i] = github.Example{Title: fmt.Sprintf("example-%03d-", i) + strings.Repeat("x", 28)}
+		pageCounts[i] = 1 + i%3
+	}
+
+	var lastHTML string
+	renders := 0
+	introPages, err := layoutIntro(discardLogger, false, func(startPage int) (int, error) {
+		renders++
+		lastHTML = htmlpdf.BuildIntroHTML(examples, startPage, pageCounts, htmlpdf.IntroOptions{})
+		return paginate(lastHTML), nil
+	})
+	if err != nil {
+		t.Fatalf("layoutIntro: %v", err)
+	}
+	if introPages < 2 {
+		t.Fatalf("a TOC of %d entries fits on %d page, the test does not cover a multi-page TOC", entries, introPages)
+	}
+
+	// The intro that goes into the book is the last one rendered
+	if got := paginate(lastHTML); got != introPages {
+		t.Errorf("the final intro has %d pages, but layoutIntro reported %d", got, introPages)
+	}
+
+	links := tocLinkPattern.FindAllStringSubmatch(lastHTML, -1)
+	if len(links) != entries {
+		t.Fatalf("the TOC has %d entries, want %d", len(links), entries)
+	}
+	page := introPages + 1
+	for i, link := range links {
+		if got, _ := strconv.Atoi(link[1]); got != page {
+			t.Fatalf("entry %d points to page %d, want %d (intro of %d pages, %d renders)", i, got, page, introPages, renders)
+		}
+		page += pageCounts[i]
+	}
+}
+
+func TestLayoutIntro(t *testing.T) {
+	tests := []struct {
+		name   string
+		duplex bool
+		// pages returns the intro's page count with the first example on startPage
+		pages     func(startPage int) int
+		want      int
+		wantPages []int // The start pages of the passes
+		wantErr   bool
+	}{
+		{"single page", false, func(int) int { return 1 }, 1, []int{2}, false},
+		{"fixed length", false, func(int) int { return 3 }, 3, []int{2, 4}, false},
+		{
+			// Three-digit page numbers wrap and need a fourth page, which
+			// the first re-render cannot know
+			"grows with the numbers", false,
+			func(start int) int {
+				if start < 4 {
+					return 3
+				}
+				return 4
+			},
+			4, []int{2, 4, 5}, false,
+		},
+		{
+			"never settles", false,
+			func(start int) int {
+				if start <= 4 {
+					return 4
+				}
+				return 3
+			},
+			0, []int{2, 5, 4, 5, 4}, true,
+		},
+		{"duplex", true, func(int) int { return 3 }, 3, []int{3, 5}, false},
+		{"duplex padding absorbs the change", true, func(int) int { return 2 }, 2, []int{3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var starts []int
+			got, err := layoutIntro(discardLogger, tt.duplex, func(startPage int) (int, error) {
+				starts = append(starts, startPage)
+				return tt.pages(startPage), nil
+			})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d pages, want %d", got, tt.want)
+			}
+			if !slices.Equal(starts, tt.wantPages) {
+				t.Errorf("rendered with start pages %v, want %v", starts, tt.wantPages)
+			}
+			if err != nil {
+				return
+			}
+
+			// The TOC of the last pass starts the examples right after the intro
+			last, want := starts[len(starts)-1], got+1
+			if tt.duplex {
+				want = evenPages(got) + 1
+			}
+			if last != want {
+				t.Errorf("the final TOC starts the examples on page %d, but the intro has %d pages", last, got)
+			}
+		})
+	}
+}
+
+func TestLayoutIntroRenderError(t *testing.T) {
+	renderErr := errors.New("browser crashed")
+	_, err := layoutIntro(discardLogger, false, func(int) (int, error) { return 0, renderErr })
+	if !errors.Is(err, renderErr) {
+		t.Errorf("got %v, want the render error", err)
+	}
+}
+
+// testPDF returns a PDF with the given number of pages
+func testPDF(t *testing.T, pages int) []byte {
+	t.Helper()
+	var specs []string
+	for i := 1; i <= pages; i++ {
+		specs = append(specs, fmt.Sprintf(`"%d": {"content": {"text": [{"value": "Page %d", "pos": [100, 700], "font": {"name": "Helvetica", "size": 24}}]}}`, i, i))
+	}
+	spec := `{"paper": "A4", "pages": {` + strings.Join(specs, ", ") + `}}`
+	var buf bytes.Buffer
+	if err := api.Create(nil, strings.NewReader(spec), &buf, nil); err != nil {
+		t.Fatalf("creating test PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// stubRendering makes renderExamples render without a browser: render is
+// called with the base name of each example's HTML file and the path the
+// PDF is to be written to
+func stubRendering(t *testing.T, render func(name, pdfPath string) error) {
+	t.Helper()
+	origOpenPage, origHTMLToPDF := openPage, htmlToPDF
+	t.Cleanup(func() { openPage, htmlToPDF = origOpenPage, origHTMLToPDF })
+
+	openPage = func(*rod.Browser) (*rod.Page, error) {
+		return nil, errors.New("no browser in tests")
+	}
+	htmlToPDF = func(_ *rod.Browser, _ *rod.Page, htmlPath, pdfPath string, _ htmlpdf.PDFOptions) error {
+		return render(strings.TrimSuffix(filepath.Base(htmlPath), ".html"), pdfPath)
+	}
+}
+
+// testExamples returns examples with plausible HTML content
+func testExamples(files ...string) []github.Example {
+	var examples []github.Example
+	for _, file := range files {
+		examples = append(examples, github.Example{
+			Title:   strings.ReplaceAll(file, "_", "-"),
+			File:    file,
+			Content: "<!DOCTYPE html><html><head><title>Go by Example: " + file + "</title></head><body><h2>" + file + "</h2></body></html>",
+		})
+	}
+	return examples
+}
+
+func TestRenderExamplesRecoversFromPanics(t *testing.T) {
+	pdf := testPDF(t, 1)
+	stubRendering(t, func(name, pdfPath string) error {
+		if name == "panics" {
+			panic("rod: unexpected browser state")
+		}
+		return os.WriteFile(pdfPath, pdf, 0644)
+	})
+
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.OutputDir, cfg.PDFDir = dir, dir
+	failures := &failureLog{}
+	rendered := renderExamples(cfg, discardLogger, progress.Nop{}, nil, dir,
+		testExamples("values", "panics", "closures"), failures)
+
+	// The panic costs only its own example
+	var got []string
+	for _, ex := range rendered.Examples {
+		got = append(got, ex.File)
+	}
+	if want := []string{"values", "closures"}; !slices.Equal(got, want) {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+
+	list := failures.list()
+	if len(list) != 1 || list[0].Example != "panics" || list[0].Stage != StageRender {
+		t.Fatalf("got failures %+v, want a render failure of panics", list)
+	}
+	if !strings.Contains(list[0].Err.Error(), "rendering panicked: rod: unexpected browser state") {
+		t.Errorf("failure %q does not carry the panic", list[0].Err)
+	}
+}
+
+func TestRenderPDFRecoversFromPanics(t *testing.T) {
+	stubRendering(t, func(string, string) error {
+		var page *rod.Page
+		return page.Navigate("file:///nowhere.html") // A nil pointer dereference
+	})
+
+	err := renderPDF(nil, nil, "example.html", "example.pdf", htmlpdf.PDFOptions{})
+	if err == nil || !strings.Contains(err.Error(), "rendering panicked") {
+		t.Errorf("got %v, want the panic as an error", err)
+	}
+}
+
+func TestRenderExamplesKeepsSlicesAligned(t *testing.T) {
+	pdfs := map[string][]byte{"values": testPDF(t, 1), "enums": testPDF(t, 3), "generics": testPDF(t, 2)}
+	stubRendering(t, func(name, pdfPath string) error {
+		switch name {
+		case "closures":
+			return errors.New("browser crashed")
+		case "structs":
+			return os.WriteFile(pdfPath, []byte("not a PDF"), 0644) // Page count fails
+		}
+		return os.WriteFile(pdfPath, pdfs[name], 0644)
+	})
+
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.OutputDir, cfg.PDFDir = dir, dir
+	cfg.NumberExamples = true
+	failures := &failureLog{}
+	rendered := renderExamples(cfg, discardLogger, progress.Nop{}, nil, dir,
+		testExamples("values", "closures", "structs", "enums", "generics"), failures)
+
+	if err := rendered.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed render is left out of every slice; the failed page count
+	// falls back to a single page but keeps the example in the book
+	wantFiles := []string{"values", "structs", "enums", "generics"}
+	wantPages := []int{1, 1, 3, 2}
+	wantNumbers := []int{1, 3, 4, 5}
+	for i, ex := range rendered.Examples {
+		if i >= len(wantFiles) || ex.File != wantFiles[i] {
+			t.Fatalf("rendered %d examples, want %q", len(rendered.Examples), wantFiles)
+		}
+		if want := filepath.Join(dir, ex.File+".pdf"); rendered.PDFPaths[i] != want {
+			t.Errorf("%s: PDF path %s, want %s", ex.File, rendered.PDFPaths[i], want)
+		}
+		if rendered.PageCounts[i] != wantPages[i] {
+			t.Errorf("%s: %d pages, want %d", ex.File, rendered.PageCounts[i], wantPages[i])
+		}
+		if rendered.Numbers[i] != wantNumbers[i] {
+			t.Errorf("%s: number %d, want %d", ex.File, rendered.Numbers[i], wantNumbers[i])
+		}
+	}
+	if len(rendered.Examples) != len(wantFiles) {
+		t.Errorf("rendered %d examples, want %d", len(rendered.Examples), len(wantFiles))
+	}
+
+	var stages []string
+	for _, f := range failures.list() {
+		stages = append(stages, f.Example+":"+f.Stage)
+	}
+	if want := []string{"closures:" + StageRender, "structs:" + StagePageCount}; !slices.Equal(stages, want) {
+		t.Errorf("got failures %q, want %q", stages, want)
+	}
+}
+
+func TestRenderResultPickAndValidate(t *testing.T) {
+	var r renderResult
+	for i, file := range []string{"values", "closures", "enums"} {
+		r.add(github.Example{File: file}, file+".pdf", i+1, i+1)
+	}
+	r.Categories = []string{"Basics", "Functions", "Types"}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	picked := r.pick([]int{2, 0})
+	if err := picked.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if picked.Examples[0].File != "enums" || picked.PDFPaths[0] != "enums.pdf" || picked.PageCounts[0] != 3 ||
+		picked.Categories[0] != "Types" || picked.Numbers[0] != 3 {
+		t.Errorf("picked %+v, want enums first with all its values", picked)
+	}
+
+	// Without numbers none are recorded, so the slices stay aligned
+	var unnumbered renderResult
+	unnumbered.add(github.Example{File: "values"}, "values.pdf", 1, 0)
+	if unnumbered.Numbers != nil {
+		t.Errorf("recorded numbers %v for unnumbered examples", unnumbered.Numbers)
+	}
+	if err := unnumbered.validate(); err != nil {
+		t.Error(err)
+	}
+
+	r.PageCounts = r.PageCounts[:2]
+	if err := r.validate(); err == nil {
+		t.Error("validate accepted 3 examples with 2 page counts")
+	}
+}
+
+func TestPrepWorkDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outputDir, "site.css"), []byte("body {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workDir, err := prepWorkDir(outputDir)
+	if err != nil {
+		t.Fatalf("prepWorkDir: %v", err)
+	}
+	if css, err := os.ReadFile(filepath.Join(workDir, "site.css")); err != nil || string(css) != "body {}" {
+		t.Errorf("site.css in the work directory = %q, %v", css, err)
+	}
+	os.RemoveAll(workDir)
+
+	// Without a site.css there is nothing to copy
+	workDir, err = prepWorkDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("prepWorkDir without site.css: %v", err)
+	}
+	os.RemoveAll(workDir)
+}
+
+func TestPrepWorkDirRemovesDirOnError(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// A directory named site.css cannot be read as a file
+	outputDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(outputDir, "site.css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prepWorkDir(outputDir); err == nil {
+		t.Fatal("prepWorkDir succeeded, want an error for the unreadable site.css")
+	}
+	if left, _ := os.ReadDir(tmp); len(left) > 0 {
+		t.Errorf("the work directory was left behind: %v", left[0].Name())
+	}
+}
+
+func TestRenderExamplesRegeneratesChangedContent(t *testing.T) {
+	pdf := testPDF(t, 1)
+	var renders []string
+	stubRendering(t, func(name, pdfPath string) error {
+		renders = append(renders, name)
+		return os.WriteFile(pdfPath, pdf, 0644)
+	})
+
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.OutputDir, cfg.PDFDir = dir, dir
+	cfg.RenderDelay = 0
+	build := func(examples []github.Example) []string {
+		t.Helper()
+		renders = nil
+		failures := &failureLog{}
+		renderExamples(cfg, discardLogger, progress.Nop{}, nil, dir, examples, failures)
+		if list := failures.list(); len(list) > 0 {
+			t.Fatalf("failures: %+v", list)
+		}
+		return renders
+	}
+
+	examples := testExamples("values", "closures")
+	if got := build(examples); !slices.Equal(got, []string{"values", "closures"}) {
+		t.Fatalf("first build rendered %q, want both examples", got)
+	}
+
+	// Unchanged upstream content reuses the files of the last build
+	if got := build(testExamples("values", "closures")); len(got) > 0 {
+		t.Errorf("rebuild with unchanged content rendered %q, want nothing", got)
+	}
+
+	// Only the example whose upstream content changed is regenerated
+	changed := testExamples("values", "closures")
+	changed[1].Content = strings.Replace(changed[1].Content, "</body>", "<p>Revised</p></body>", 1)
+	if got := build(changed); !slices.Equal(got, []string{"closures"}) {
+		t.Errorf("rebuild with changed closures rendered %q, want [closures]", got)
+	}
+	if html, err := os.ReadFile(filepath.Join(dir, "closures.html")); err != nil || !strings.Contains(string(html), "Revised") {
+		t.Errorf("closures.html does not have the changed content (%v)", err)
+	}
+	if got := build(changed); len(got) > 0 {
+		t.Errorf("rebuild after the change rendered %q, want nothing", got)
+	}
+}
@@ -0,0 +1,89 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/htmlpdf"
+	"go-by-example-book/internal/manifest"
+	"go-by-example-book/internal/pdfutil"
+)
+
+// errNoCachedExamples is returned when an intro-only build finds nothing to
+// build from
+var errNoCachedExamples = errors.New("no cached example PDFs found; run a full build first")
+
+// loadCachedExamples collects the per-example PDFs of a previous build
+//
+// This is the source of the examples for cfg.IntroOnly: instead of fetching
+// and rendering, the examples recorded in the manifest are used as they are,
+// so only the front matter, merge and bookmarks have to be redone. The
+// examples are ordered and filtered like the upstream listing, by their
+// upstream filename. Examples whose PDF is gone are recorded as failures.
+//
+// Parameters:
+//   - logger: The logger for progress output
+//   - outputDir: The output directory of the previous build
+//   - pdfDir: The directory of the per-example PDFs
+//   - include, exclude, limit: The same filters as for a full build
+//   - failures: Collects examples that could not be loaded
+//
+// Returns:
+//   - renderResult: The cached examples with their PDFs and page counts
+//   - error: Any error that occurred while reading the manifest, or errNoCachedExamples
+func loadCachedExamples(logger *slog.Logger, outputDir, pdfDir string, include, exclude *regexp.Regexp, limit int, failures *failureLog) (renderResult, error) {
+	buildManifest, err := manifest.Load(filepath.Join(outputDir, manifest.FileName))
+	if err != nil {
+		return renderResult{}, err
+	}
+
+	// The upstream filename is the last element of the source URL
+	files := make(map[string]string, len(buildManifest.Examples))
+	var names []string
+	for file, entry := range buildManifest.Examples {
+		name := path.Base(entry.SourceURL)
+		files[name] = file
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = github.FilterExampleFiles(names, include, exclude)
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+
+	var rendered renderResult
+	for _, name := range names {
+		file := files[name]
+		fileStatus := htmlpdf.ReceiveOutputFileStatus(outputDir, pdfDir, file)
+		if !fileStatus.PDFExists {
+			failures.add(file, StageRender, fmt.Errorf("no cached PDF at %s", fileStatus.PDFPath))
+			continue
+		}
+
+		pageCount, err := pdfutil.PageCount(fileStatus.PDFPath)
+		if err != nil {
+			failures.add(file, StagePageCount, err)
+			continue
+		}
+
+		entry, _ := buildManifest.Get(file)
+		rendered.add(github.Example{
+			Title:     name,
+			File:      file,
+			SourceURL: entry.SourceURL,
+			Source:    github.Cached,
+		}, fileStatus.PDFPath, pageCount, 0)
+	}
+
+	if len(rendered.Examples) == 0 {
+		return renderResult{}, errNoCachedExamples
+	}
+	logger.Info(fmt.Sprintf("Using %d cached example PDFs", len(rendered.Examples)))
+	return rendered, nil
+}
@@ -0,0 +1,238 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go-by-example-book/internal/htmlpdf"
+	"go-by-example-book/internal/naming"
+)
+
+// FileConfig is the content of a JSON config file
+//
+// The keys are the names of the command-line flags, so a build can be moved
+// from a command line into a file one option at a time. Every field is
+// optional; a key that is missing keeps the value of the Config the file is
+// applied to. Options that only affect the command-line tool, like logging,
+// the theme and preface files, -date or -since, cannot be set in a file.
+//
+// Example:
+//
+//	{
+//	    "o": "book-concurrency.pdf",
+//	    "include": "channel|goroutine|mutex",
+//	    "request-delay": "500ms",
+//	    "watermark": "DRAFT",
+//	    "layout": "two-column"
+//	}
+type FileConfig struct {
+	OutputDir      *string   `json:"out"`
+	PDFDir         *string   `json:"pdf-dir"`
+	FinalPDF       *string   `json:"o"`
+	RepoDir        *string   `json:"repo-dir"`
+	RawBaseURL     *string   `json:"raw-base-url"`
+	Concurrency    *int      `json:"concurrency"`
+	Threshold      *float64  `json:"threshold"`
+	MinWords       *int      `json:"min-words"`
+	MatchMetric    *string   `json:"match-metric"`
+	RequestDelay   *Duration `json:"request-delay"`
+	UserAgent      *string   `json:"user-agent"`
+	MinContentSize *int      `json:"min-size"`
+	Limit          *int      `json:"limit"`
+	Include        *string   `json:"include"`
+	Exclude        *string   `json:"exclude"`
+	BrowserBinPath *string   `json:"browser"`
+	NoSandbox      *bool     `json:"no-sandbox"`
+
+	InlineAssets    *bool     `json:"inline-assets"`
+	KeepInteractive *bool     `json:"keep-buttons"`
+	NormalizeHTML   *bool     `json:"normalize-html"`
+	SourceFooter    *bool     `json:"source-url"`
+	NumberExamples  *bool     `json:"number-examples"`
+	ValidateHTML    *bool     `json:"validate-html"`
+	FontFiles       []string  `json:"font"`
+	Appendices      []string  `json:"appendix"`
+	Index           *bool     `json:"index"`
+	MarkdownFiles   []string  `json:"markdown"`
+	MarkdownOrder   *string   `json:"markdown-order"`
+	Scale           *float64  `json:"scale"`
+	DeviceScale     *float64  `json:"device-scale"`
+	Layout          *string   `json:"layout"`
+	WaitSelector    *string   `json:"wait-for"`
+	WaitTimeout     *Duration `json:"wait-timeout"`
+	RenderDelay     *Duration `json:"render-delay"`
+
+	CombinedHTML    *string  `json:"html"`
+	HTMLPageBreaks  *bool    `json:"html-page-breaks"`
+	CodeDir         *string  `json:"code"`
+	Force           *bool    `json:"force"`
+	RefreshAssets   *bool    `json:"refresh-assets"`
+	IntroOnly       *bool    `json:"intro-only"`
+	ExamplesOnly    *bool    `json:"examples-only"`
+	NoMerge         *bool    `json:"no-merge"`
+	GroupByCategory *bool    `json:"group"`
+	SplitByCategory *bool    `json:"split"`
+	Duplex          *bool    `json:"duplex"`
+	Optimize        *bool    `json:"optimize"`
+	KeepTemp        *bool    `json:"keep-temp"`
+	CostPerPage     *float64 `json:"cost-per-page"`
+	Validation      *string  `json:"validate"`
+
+	WatermarkText     *string  `json:"watermark"`
+	WatermarkOpacity  *float64 `json:"watermark-opacity"`
+	WatermarkRotation *float64 `json:"watermark-rotation"`
+	WatermarkColor    *string  `json:"watermark-color"`
+
+	UserPassword  *string `json:"user-password"`
+	OwnerPassword *string `json:"owner-password"`
+	AllowPrint    *bool   `json:"allow-print"`
+	AllowCopy     *bool   `json:"allow-copy"`
+}
+
+// Duration is a time.Duration written as a string like "500ms" or "2s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, see time.ParseDuration
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"500ms\": %v", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadConfigFile applies the options of a JSON config file to cfg
+//
+// Unknown keys are rejected, so a misspelled option fails loudly instead of
+// being ignored. The values are not validated beyond their type; callers
+// check them like command-line values.
+//
+// Parameters:
+//   - path: The config file to read
+//   - cfg: The configuration to update, usually DefaultConfig()
+//
+// Returns:
+//   - error: Any error that occurred while reading or decoding the file
+//
+// Example:
+//
+//	cfg := DefaultConfig()
+//	if err := LoadConfigFile("book.json", &cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+func LoadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %v", err)
+	}
+
+	var fc FileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	fc.apply(cfg)
+	return nil
+}
+
+// apply copies the options set in the file to cfg
+func (fc FileConfig) apply(cfg *Config) {
+	set(&cfg.OutputDir, fc.OutputDir)
+	set(&cfg.PDFDir, fc.PDFDir)
+	set(&cfg.FinalPDF, fc.FinalPDF)
+	set(&cfg.RepoDir, fc.RepoDir)
+	set(&cfg.RawBaseURL, fc.RawBaseURL)
+	set(&cfg.Concurrency, fc.Concurrency)
+	set(&cfg.Threshold, fc.Threshold)
+	set(&cfg.MinWords, fc.MinWords)
+	if fc.MatchMetric != nil {
+		cfg.MatchMetric = naming.Metric(*fc.MatchMetric)
+	}
+	if fc.RequestDelay != nil {
+		cfg.RequestDelay = time.Duration(*fc.RequestDelay)
+	}
+	set(&cfg.UserAgent, fc.UserAgent)
+	set(&cfg.MinContentSize, fc.MinContentSize)
+	set(&cfg.Limit, fc.Limit)
+	set(&cfg.Include, fc.Include)
+	set(&cfg.Exclude, fc.Exclude)
+	set(&cfg.BrowserBinPath, fc.BrowserBinPath)
+	set(&cfg.NoSandbox, fc.NoSandbox)
+
+	set(&cfg.InlineAssets, fc.InlineAssets)
+	set(&cfg.KeepInteractive, fc.KeepInteractive)
+	set(&cfg.NormalizeHTML, fc.NormalizeHTML)
+	set(&cfg.SourceFooter, fc.SourceFooter)
+	set(&cfg.NumberExamples, fc.NumberExamples)
+	set(&cfg.ValidateHTML, fc.ValidateHTML)
+	if fc.FontFiles != nil {
+		cfg.FontFiles = fc.FontFiles
+	}
+	if fc.Appendices != nil {
+		cfg.Appendices = fc.Appendices
+	}
+	set(&cfg.Index, fc.Index)
+	if fc.MarkdownFiles != nil {
+		cfg.MarkdownFiles = fc.MarkdownFiles
+	}
+	if fc.MarkdownOrder != nil {
+		cfg.MarkdownOrder = MarkdownOrder(*fc.MarkdownOrder)
+	}
+	set(&cfg.Scale, fc.Scale)
+	set(&cfg.DeviceScale, fc.DeviceScale)
+	if fc.Layout != nil {
+		cfg.Layout = htmlpdf.Layout(*fc.Layout)
+	}
+	set(&cfg.WaitSelector, fc.WaitSelector)
+	if fc.WaitTimeout != nil {
+		cfg.WaitTimeout = time.Duration(*fc.WaitTimeout)
+	}
+	if fc.RenderDelay != nil {
+		cfg.RenderDelay = time.Duration(*fc.RenderDelay)
+	}
+
+	set(&cfg.CombinedHTML, fc.CombinedHTML)
+	set(&cfg.HTMLPageBreaks, fc.HTMLPageBreaks)
+	set(&cfg.CodeDir, fc.CodeDir)
+	set(&cfg.Force, fc.Force)
+	set(&cfg.RefreshAssets, fc.RefreshAssets)
+	set(&cfg.IntroOnly, fc.IntroOnly)
+	set(&cfg.ExamplesOnly, fc.ExamplesOnly)
+	set(&cfg.NoMerge, fc.NoMerge)
+	set(&cfg.GroupByCategory, fc.GroupByCategory)
+	set(&cfg.SplitByCategory, fc.SplitByCategory)
+	set(&cfg.Duplex, fc.Duplex)
+	set(&cfg.Optimize, fc.Optimize)
+	set(&cfg.KeepTemp, fc.KeepTemp)
+	set(&cfg.CostPerPage, fc.CostPerPage)
+	if fc.Validation != nil {
+		cfg.Validation = ValidationMode(*fc.Validation)
+	}
+
+	set(&cfg.Watermark.Text, fc.WatermarkText)
+	set(&cfg.Watermark.Opacity, fc.WatermarkOpacity)
+	set(&cfg.Watermark.Rotation, fc.WatermarkRotation)
+	set(&cfg.Watermark.Color, fc.WatermarkColor)
+
+	set(&cfg.Encryption.UserPassword, fc.UserPassword)
+	set(&cfg.Encryption.OwnerPassword, fc.OwnerPassword)
+	set(&cfg.Encryption.AllowPrint, fc.AllowPrint)
+	set(&cfg.Encryption.AllowCopy, fc.AllowCopy)
+}
+
+// set assigns *value to *dst if the option was present in the file
+func set[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
+	}
+}
@@ -0,0 +1,70 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"go-by-example-book/internal/logging"
+)
+
+// ErrExamplesFailed is returned by Run when the book was generated but some
+// examples could not be fetched or rendered and are missing from it
+var ErrExamplesFailed = errors.New("some examples failed")
+
+// Stages at which an example can fail
+const (
+	StageDownload  = "download"
+	StageHTML      = "html"
+	StageRender    = "render"
+	StagePageCount = "page count"
+)
+
+// Failure describes an example that failed at one stage of the build
+type Failure struct {
+	Example string // The example's filename or title
+	Stage   string // The stage that failed, e.g. StageDownload
+	Err     error  // The underlying error
+}
+
+// failureLog collects the failures of a build; it is safe for concurrent use
+type failureLog struct {
+	mu       sync.Mutex
+	failures []Failure
+}
+
+// add records a failure
+func (f *failureLog) add(example, stage string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, Failure{Example: example, Stage: stage, Err: err})
+}
+
+// list returns a copy of the recorded failures in the order they occurred
+func (f *failureLog) list() []Failure {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Failure{}, f.failures...)
+}
+
+// report logs a consolidated summary of all failures
+//
+// Failures are logged as they happen too, but those lines are easily lost
+// in the output of a long build. The summary at the end lists them all in
+// one place.
+//
+// Returns:
+//   - error: An error wrapping ErrExamplesFailed if any failure was recorded, nil otherwise
+func (f *failureLog) report(logger *slog.Logger) error {
+	failures := f.list()
+	if len(failures) == 0 {
+		return nil
+	}
+
+	logger.Warn(fmt.Sprintf("%d example failure(s):", len(failures)), logging.Tag("FAILED"))
+	for _, failure := range failures {
+		logger.Warn(fmt.Sprintf("  %s (%s): %v", failure.Example, failure.Stage, failure.Err))
+	}
+	return fmt.Errorf("%w: %d failure(s), see the summary above", ErrExamplesFailed, len(failures))
+}
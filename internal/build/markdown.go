@@ -0,0 +1,121 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/htmlpdf"
+	"go-by-example-book/internal/manifest"
+)
+
+// MarkdownOrder controls where the Markdown lessons are placed in the book
+type MarkdownOrder string
+
+const (
+	MarkdownEnd    MarkdownOrder = "end"    // After the examples
+	MarkdownStart  MarkdownOrder = "start"  // Before the examples
+	MarkdownSorted MarkdownOrder = "sorted" // Among the examples, sorted by title
+)
+
+// markdownFilePrefix starts the file names of the Markdown lessons, so a
+// lesson named like an upstream example cannot overwrite its files
+const markdownFilePrefix = "lesson_"
+
+// loadMarkdownExamples converts the user's Markdown lessons into examples
+//
+// Every path is a .md file or a directory whose .md files are used in name
+// order. The title of a lesson is its first level-1 heading, or its file
+// name without the extension, and its last change is the file's
+// modification time. The lessons are styled with the site's CSS
+// like the upstream examples, see htmlpdf.MarkdownToHTML.
+//
+// Parameters:
+//   - paths: The Markdown files and directories
+//
+// Returns:
+//   - []github.Example: The lessons, in the order of paths
+//   - error: Any error that occurred while reading or converting a file
+func loadMarkdownExamples(paths []string) ([]github.Example, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read Markdown: %v", err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(p, "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("could not list Markdown files in %s: %v", p, err)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	lessons := make([]github.Example, 0, len(files))
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read Markdown: %v", err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read Markdown: %v", err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		title := htmlpdf.MarkdownTitle(string(src))
+		if title == "" {
+			title = name
+		}
+		content, err := htmlpdf.MarkdownToHTML(string(src), title)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve %s: %v", file, err)
+		}
+		lessons = append(lessons, github.Example{
+			Title:       title,
+			Content:     content,
+			File:        markdownFilePrefix + strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+			SourceURL:   "file://" + filepath.ToSlash(absPath),
+			Source:      github.Markdown,
+			ContentHash: manifest.HashContent(content),
+
+			LastModified: info.ModTime(),
+		})
+	}
+	return lessons, nil
+}
+
+// addMarkdownExamples places the lessons among the examples
+//
+// With MarkdownSorted, the lessons are sorted in by title, ignoring case;
+// the examples keep their order among each other.
+//
+// Returns:
+//   - []github.Example: The examples and lessons in book order
+func addMarkdownExamples(examples, lessons []github.Example, order MarkdownOrder) []github.Example {
+	combined := make([]github.Example, 0, len(examples)+len(lessons))
+	switch order {
+	case MarkdownStart:
+		combined = append(append(combined, lessons...), examples...)
+	case MarkdownSorted:
+		combined = append(append(combined, examples...), lessons...)
+		sort.SliceStable(combined, func(i, j int) bool {
+			return strings.ToLower(combined[i].Title) < strings.ToLower(combined[j].Title)
+		})
+	default:
+		combined = append(append(combined, examples...), lessons...)
+	}
+	return combined
+}
@@ -0,0 +1,82 @@
+package build
+
+import (
+	"encoding/json"
+
+	"go-by-example-book/internal/github"
+)
+
+// Result describes the outcome of a build in machine-readable form
+//
+// It is meant to be serialized as JSON for CI pipelines and other tooling
+// that indexes the book.
+type Result struct {
+	Outputs    []Output  `json:"outputs"`         // The written books or booklets, in the order they were created
+	TotalPages int       `json:"totalPages"`      // Sum of the page counts of all outputs
+	Failures   []Failure `json:"failures"`        // Examples that are missing from the outputs
+	Error      string    `json:"error,omitempty"` // The error that ended the build, if any
+}
+
+// Output describes one written file
+type Output struct {
+	Path       string         `json:"path"`                 // Path of the PDF (or combined HTML) file
+	TotalPages int            `json:"totalPages,omitempty"` // Number of pages; 0 for HTML output
+	Examples   []ExampleRange `json:"examples"`             // The examples in the file, in book order
+}
+
+// ExampleRange locates an example in an output
+type ExampleRange struct {
+	File      string `json:"file"`                // The sanitized filename of the example
+	Title     string `json:"title"`               // The example's display title
+	FirstPage int    `json:"firstPage,omitempty"` // 1-based page the example starts on; 0 for HTML output
+	LastPage  int    `json:"lastPage,omitempty"`  // 1-based page the example ends on, including padding pages
+}
+
+// MarshalJSON encodes a failure with its error as a string
+func (f Failure) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Example string `json:"example"`
+		Stage   string `json:"stage"`
+		Error   string `json:"error"`
+	}{f.Example, f.Stage, f.Err.Error()})
+}
+
+// newOutput describes a PDF whose examples follow introPages pages of intro
+//
+// Parameters:
+//   - path: The path of the PDF
+//   - introPages: The number of pages before the first example
+//   - laidOut: The examples with the page counts they occupy in the PDF
+//
+// Returns:
+//   - Output: The output with the page range of every example
+func newOutput(path string, introPages int, laidOut renderResult) Output {
+	out := Output{Path: path, Examples: make([]ExampleRange, len(laidOut.Examples))}
+	page := introPages
+	for i, ex := range laidOut.Examples {
+		out.Examples[i] = ExampleRange{
+			File:      ex.File,
+			Title:     ex.Title,
+			FirstPage: page + 1,
+			LastPage:  page + laidOut.PageCounts[i],
+		}
+		page += laidOut.PageCounts[i]
+	}
+	out.TotalPages = page
+	return out
+}
+
+// newHTMLOutput describes a combined HTML file, which has no pages
+func newHTMLOutput(path string, examples []github.Example) Output {
+	out := Output{Path: path, Examples: make([]ExampleRange, len(examples))}
+	for i, ex := range examples {
+		out.Examples[i] = ExampleRange{File: ex.File, Title: ex.Title}
+	}
+	return out
+}
+
+// add records an output and its pages
+func (r *Result) add(out Output) {
+	r.Outputs = append(r.Outputs, out)
+	r.TotalPages += out.TotalPages
+}
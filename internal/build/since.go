@@ -0,0 +1,85 @@
+package build
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/logging"
+	"go-by-example-book/internal/manifest"
+)
+
+// filterChangedSince keeps the examples that changed at or after since
+//
+// The time of the last change is the example's LastModified, from the
+// download or the local file. Examples reused without downloading them
+// again have none; for them the time recorded in the manifest of the
+// previous build is used. Examples whose time is still unknown are kept, so
+// a supplement never misses a change.
+//
+// Parameters:
+//   - logger: The logger for the summary
+//   - outputDir: The output directory holding the manifest
+//   - examples: The examples to filter
+//   - since: The earliest change to include
+//
+// Returns:
+//   - []github.Example: The examples changed since the date, in their order
+func filterChangedSince(logger *slog.Logger, outputDir string, examples []github.Example, since time.Time) []github.Example {
+	buildManifest, err := manifest.Load(filepath.Join(outputDir, manifest.FileName))
+	if err != nil {
+		logger.Warn("Could not load manifest, dates of reused examples are unknown", "err", err)
+		buildManifest = manifest.New()
+	}
+
+	var kept []github.Example
+	unknown := 0
+	for _, ex := range examples {
+		modified := lastModified(buildManifest, ex)
+		if modified.IsZero() {
+			unknown++
+		} else if modified.Before(since) {
+			logger.Debug("Unchanged since the -since date, skipping", "example", ex.Title, "modified", modified)
+			continue
+		}
+		kept = append(kept, ex)
+	}
+
+	logger.Info(fmt.Sprintf("%d of %d examples changed since %s (%d with unknown date)",
+		len(kept), len(examples), since.Format(time.DateOnly), unknown), logging.Tag("SINCE"))
+	return kept
+}
+
+// lastModified returns when an example last changed, or the zero time if
+// that is unknown
+//
+// The manifest is only consulted for content that was reused as it is; a
+// download without a date may differ from what the manifest recorded.
+func lastModified(m *manifest.Manifest, ex github.Example) time.Time {
+	if !ex.LastModified.IsZero() {
+		return ex.LastModified
+	}
+	if ex.Source == github.Cached || ex.Source == github.LocalMatch {
+		if entry, ok := m.Get(ex.File); ok {
+			return entry.LastModified
+		}
+	}
+	return time.Time{}
+}
+
+// recordedLastModified returns the time to record as the last change of an
+// example in the manifest
+//
+// A date reported for the content wins. Otherwise the recorded date is kept
+// while the content is unchanged, and a new or changed content is dated now.
+func recordedLastModified(m *manifest.Manifest, ex github.Example, contentHash string) time.Time {
+	if !ex.LastModified.IsZero() {
+		return ex.LastModified.UTC()
+	}
+	if entry, ok := m.Get(ex.File); ok && !m.Changed(ex.File, contentHash) {
+		return entry.LastModified
+	}
+	return time.Now().UTC()
+}
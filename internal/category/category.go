@@ -0,0 +1,73 @@
+// Package category assigns Go by Example examples to topical categories.
+//
+// The gobyexample site lists its examples in one long sequence without any
+// explicit grouping. This package derives a category from the example's
+// name using keyword rules, which is enough to split the book into booklets
+// like "Concurrency" or "Files & System".
+//
+// Example usage:
+//
+//	category.Of("worker-pools")    // Returns: "Concurrency"
+//	category.Of("reading_files")   // Returns: "Files & System"
+//	category.Of("hello-world")     // Returns: "Basics"
+package category
+
+import "strings"
+
+// Basics is the category of examples that match no other rule
+const Basics = "Basics"
+
+// rule maps examples whose name contains one of the keywords to a category
+type rule struct {
+	name     string
+	keywords []string
+}
+
+// rules are checked in order and the first match wins, so more specific
+// categories come first (e.g. "range-over-channels" is Concurrency, not
+// Data Structures)
+var rules = []rule{
+	{"Concurrency", []string{"goroutine", "channel", "select", "timeout", "timer", "ticker", "worker", "waitgroup", "rate-limiting", "atomic", "mutex", "context"}},
+	{"Networking", []string{"http"}},
+	{"Errors", []string{"error", "panic", "defer", "recover"}},
+	{"Files & System", []string{"file", "director", "line-filter", "embed-directive", "command-line", "environment", "logging", "process", "signal", "exit", "testing"}},
+	{"Time & Numbers", []string{"time", "epoch", "random", "number"}},
+	{"Text & Encoding", []string{"string", "text", "regular-expression", "json", "xml", "base64", "sha256", "url"}},
+	{"Types & Methods", []string{"method", "interface", "enum", "embedding", "generic", "iterator"}},
+	{"Data Structures", []string{"array", "slice", "map", "struct", "range", "sort"}},
+}
+
+// Names returns all categories in book order, starting with Basics
+//
+// Returns:
+//   - []string: The category names
+func Names() []string {
+	names := make([]string, 0, len(rules)+1)
+	names = append(names, Basics)
+	for _, r := range rules {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// Of returns the category of an example
+//
+// The name may be an upstream filename ("worker-pools") or a sanitized one
+// ("worker_pools"); matching is case-insensitive.
+//
+// Parameters:
+//   - name: The example's filename or title
+//
+// Returns:
+//   - string: The category name, Basics if no rule matches
+func Of(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	for _, r := range rules {
+		for _, kw := range r.keywords {
+			if strings.Contains(name, kw) {
+				return r.name
+			}
+		}
+	}
+	return Basics
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for the directory listing, wrapped by GetGitHubFiles and
+// GetExampleFilesFromListing so callers can tell them apart with errors.Is
+var (
+	// ErrListingUnavailable means the listing page could not be fetched,
+	// e.g. because the network or GitHub is down
+	ErrListingUnavailable = errors.New("example listing unavailable")
+	// ErrNoExamplesFound means the listing was fetched but yielded no
+	// examples, which usually means GitHub changed its page format
+	ErrNoExamplesFound = errors.New("no examples found in listing")
+)
+
+// maxErrorBodySnippet limits how much of an error response body is kept
+const maxErrorBodySnippet = 200
+
+// HTTPError reports an unexpected HTTP status for a request
+//
+// Use errors.As to inspect the status code:
+//
+//	var httpErr *github.HTTPError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+//	    // the file does not exist upstream
+//	}
+type HTTPError struct {
+	URL        string // The requested URL
+	StatusCode int    // The HTTP status code of the response
+	Status     string // The HTTP status line, e.g. "404 Not Found"
+	Body       string // The beginning of the response body, for debugging
+}
+
+// Error returns the status together with the URL and the body snippet
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("HTTP %s for %s", e.Status, e.URL)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// newHTTPError creates an HTTPError from a response with an unexpected status
+//
+// At most maxErrorBodySnippet bytes of the body are read; whitespace is
+// collapsed so the snippet fits on one log line.
+func newHTTPError(url string, resp *http.Response) *HTTPError {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySnippet))
+	body := strings.Join(strings.Fields(string(snippet)), " ")
+	if len(snippet) == maxErrorBodySnippet {
+		body += "..."
+	}
+
+	return &HTTPError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+}
+
+// ContentTypeError reports a successful response of an unexpected type
+//
+// A proxy or captive portal can answer with its own HTML page and status
+// 200; without this check such a page would be saved as an example or
+// asset. Use errors.As to inspect the received type.
+type ContentTypeError struct {
+	URL         string   // The requested URL
+	ContentType string   // The media type of the response, e.g. "text/html"
+	Accepted    []string // The media types the request accepted
+}
+
+// Error returns the received and the accepted types together with the URL
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content type %q for %s (expected %s)", e.ContentType, e.URL, strings.Join(e.Accepted, ", "))
+}
+
+// checkContentType checks the Content-Type header of a response against the
+// accepted media types
+//
+// Parameters are ignored when comparing, so "text/plain; charset=utf-8"
+// matches "text/plain". A missing header cannot be judged and is accepted,
+// as is any type when accept is empty.
+//
+// Returns:
+//   - error: A *ContentTypeError if the type is not accepted
+func checkContentType(url string, header string, accept []string) error {
+	if len(accept) == 0 || header == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(header, ";")[0]))
+	}
+	for _, accepted := range accept {
+		if mediaType == accepted {
+			return nil
+		}
+	}
+	return &ContentTypeError{URL: url, ContentType: mediaType, Accepted: accept}
+}
@@ -0,0 +1,183 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ETagFileName is the name of the shared ETag index inside the output directory
+const ETagFileName = "etags.json"
+
+// ETagCache stores the ETag returned for each downloaded URL
+//
+// The cache is persisted as a small JSON file so that later runs can send
+// conditional requests and skip downloading content that has not changed.
+//
+// The cache is safe for concurrent use through its methods.
+type ETagCache struct {
+	mu    sync.Mutex
+	path  string            // Where the cache is persisted
+	ETags map[string]string // ETag values keyed by URL
+}
+
+// LoadETagCache reads the ETag index from the given path
+//
+// A missing file yields an empty cache, so the first run simply downloads
+// everything and records the ETags for the next one.
+//
+// Parameters:
+//   - path: The path to the ETag index file
+//
+// Returns:
+//   - *ETagCache: The loaded (or empty) cache
+//   - error: Any error that occurred while reading or parsing the file
+func LoadETagCache(path string) (*ETagCache, error) {
+	cache := &ETagCache{path: path, ETags: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ETag cache: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &cache.ETags); err != nil {
+		return nil, fmt.Errorf("failed to parse ETag cache %s: %v", path, err)
+	}
+	if cache.ETags == nil {
+		cache.ETags = make(map[string]string)
+	}
+
+	return cache, nil
+}
+
+// Save writes the ETag index back to the path it was loaded from
+func (c *ETagCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.ETags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ETag cache: %v", err)
+	}
+
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// Get returns the ETag stored for a URL and whether one exists
+func (c *ETagCache) Get(url string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	etag, ok := c.ETags[url]
+	return etag, ok
+}
+
+// Set records the ETag for a URL
+func (c *ETagCache) Set(url, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ETags[url] = etag
+}
+
+// DownloadCached downloads a URL unless the local copy is still current
+//
+// If an ETag is stored for the URL and the local file exists, the request is
+// sent with an If-None-Match header. A 304 Not Modified response means the
+// local copy is reused as-is; any other successful response replaces it and
+// the new ETag is recorded in the cache.
+//
+// Parameters:
+//   - url: The URL to download
+//   - localPath: The path of the previously downloaded copy
+//   - cache: The ETag cache to consult and update
+//   - accept: The media types the content may have, e.g. ExampleContentTypes;
+//     empty accepts any type
+//
+// Returns:
+//   - string: The content, either downloaded or read from localPath
+//   - bool: true if the local copy was reused because it was not modified
+//   - time.Time: The Last-Modified time of the response; zero if the server
+//     sent none
+//   - error: Any error that occurred during the process; a response of
+//     another type is rejected with a *ContentTypeError
+func DownloadCached(url, localPath string, cache *ETagCache, accept []string) (string, bool, time.Time, error) {
+	etag, _ := cache.Get(url)
+	if _, err := os.Stat(localPath); err != nil {
+		etag = "" // Without a local copy there is nothing to revalidate
+	}
+
+	content, newETag, lastModified, notModified, err := downloadFileIfChanged(url, etag, accept)
+	if err != nil {
+		return "", false, time.Time{}, err
+	}
+
+	if notModified {
+		local, err := os.ReadFile(localPath)
+		if err != nil {
+			return "", false, time.Time{}, fmt.Errorf("failed to read cached copy %s: %v", localPath, err)
+		}
+		return string(local), true, lastModified, nil
+	}
+
+	if newETag != "" {
+		cache.Set(url, newETag)
+	}
+	return content, false, lastModified, nil
+}
+
+// downloadFileIfChanged performs a conditional GET request
+//
+// When etag is non-empty it is sent as If-None-Match, and a 304 response is
+// reported through notModified instead of being treated as an error. A
+// successful response whose type is not in accept is rejected, see
+// checkContentType. lastModified is parsed from the Last-Modified header
+// and zero if it is missing or malformed.
+func downloadFileIfChanged(url, etag string, accept []string) (content string, newETag string, lastModified time.Time, notModified bool, err error) {
+	req, err := newRequest(url)
+	if err != nil {
+		return "", "", time.Time{}, false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", time.Time{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if header := resp.Header.Get("Last-Modified"); header != "" {
+		if t, err := http.ParseTime(header); err == nil {
+			lastModified = t
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return "", etag, lastModified, true, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", time.Time{}, false, newHTTPError(url, resp)
+	}
+
+	if err := checkContentType(url, resp.Header.Get("Content-Type"), accept); err != nil {
+		return "", "", time.Time{}, false, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", time.Time{}, false, err
+	}
+
+	return string(body), resp.Header.Get("ETag"), lastModified, false, nil
+}
@@ -24,29 +24,242 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"go-by-example-book/internal/logging"
+	"go-by-example-book/internal/manifest"
 	"go-by-example-book/internal/naming"
+	"go-by-example-book/internal/ratelimit"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// logger receives all diagnostic output of the package
+var logger = logging.Default()
+
+// SetLogger replaces the logger used for the package's diagnostic output
+//
+// This allows callers to control verbosity and redirect output, e.g. to a
+// file. Passing nil restores the default logger.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Default()
+	}
+	logger = l
+}
+
+// DefaultHTTPTimeout is the timeout applied to every HTTP request by default
+const DefaultHTTPTimeout = 30 * time.Second
+
+// httpClient is shared by all requests of the package so a stalled
+// connection cannot hang the run indefinitely
+var httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+// SetHTTPTimeout overrides the timeout of the shared HTTP client
+//
+// A timeout of zero disables the limit entirely, which matches the behavior
+// of http.DefaultClient.
+func SetHTTPTimeout(timeout time.Duration) {
+	httpClient.Timeout = timeout
+}
+
+// DefaultUserAgent is the User-Agent sent with every request by default
+//
+// GitHub and some proxies throttle requests with Go's default User-Agent
+// harder than those of an identifiable client.
+const DefaultUserAgent = "go-by-example-book/1.0 (+https://github.com/wunderkind2k1/go-by-example-book-generator)"
+
+// userAgent is the User-Agent sent with every request of the package
+var userAgent = DefaultUserAgent
+
+// SetUserAgent overrides the User-Agent sent with every request
+//
+// Passing an empty string restores DefaultUserAgent.
+func SetUserAgent(ua string) {
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	userAgent = ua
+}
+
+// newRequest creates a GET request with the package's User-Agent
+//
+// All requests of the package are built with it and sent with httpClient,
+// so the User-Agent and the timeout apply to every one of them.
+func newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// Default upstream locations of the gobyexample site
+const (
+	// DefaultListingURL is the GitHub page listing the published example files
+	DefaultListingURL = "https://github.com/mmcgrana/gobyexample/tree/master/public"
+	// DefaultRawBaseURL is the base URL the raw example files and assets are served from
+	DefaultRawBaseURL = "https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public"
+)
+
+// ExampleContentTypes are the media types accepted for an example's HTML
+//
+// raw.githubusercontent.com serves every text file as text/plain, while a
+// mirror of the site serves the pages as text/html.
+var ExampleContentTypes = []string{"text/html", "text/plain"}
+
+// assetContentTypes are the media types accepted for an asset, by file
+// extension; assets with other extensions are accepted with any type
+var assetContentTypes = map[string][]string{
+	".css": {"text/css", "text/plain"},
+	".js":  {"text/javascript", "application/javascript", "application/x-javascript", "text/plain"},
+	".png": {"image/png"},
+}
+
+// Options controls how GetGitHubFiles matches and fetches examples
+type Options struct {
+	Threshold   float64 // Minimum word overlap (0.0-1.0) for reusing an existing local HTML file
+	Concurrency int     // Number of examples fetched in parallel
+	Limit       int     // Only process the first Limit examples of the listing; 0 processes all
+
+	// MinWords is the minimum number of words both filenames need for their
+	// overlap to be trusted; shorter names must match exactly. See
+	// naming.Matches.
+	MinWords int
+
+	// Metric measures the overlap compared against Threshold; empty means
+	// naming.MetricJaccard
+	Metric naming.Metric
+
+	// MinContentSize is the minimum size in bytes of an example's HTML;
+	// smaller bodies, like soft-404 pages, are skipped. 0 disables the check.
+	MinContentSize int
+
+	// RequestDelay is the minimum time between two requests to upstream,
+	// shared by all workers; 0 disables the delay
+	RequestDelay time.Duration
+
+	// Include and Exclude filter the listing by example filename; nil
+	// disables the filter. Exclude wins when a name matches both.
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+
+	// ListingURL and RawBaseURL locate the upstream listing page and raw
+	// files; empty uses DefaultListingURL and DefaultRawBaseURL. Overriding
+	// them allows pointing the generator at a mirror or a local test server.
+	ListingURL string
+	RawBaseURL string
+
+	// Context stops fetching further examples when it is canceled; the
+	// examples fetched so far are returned. nil never cancels.
+	Context context.Context
+
+	// Force downloads every example, even if a matching local HTML file
+	// exists, and the assets like RefreshAssets
+	Force bool
+
+	// RefreshAssets downloads site.css and the other assets even if they
+	// already exist in the output directory
+	RefreshAssets bool
+
+	// OnFailure, if set, is called for every example that is skipped
+	// because it could not be fetched. It may be called concurrently.
+	OnFailure func(filename string, err error)
+
+	// RepoDir is the root of a local gobyexample clone. When set, the
+	// listing, the examples and the assets are read from its public/
+	// directory and no HTTP requests are made at all.
+	RepoDir string
+}
+
+// DefaultMinContentSize is the default minimum size of an example's HTML in
+// bytes; real example pages are several kilobytes
+const DefaultMinContentSize = 1024
+
+// DefaultRequestDelay is the default minimum time between two upstream requests
+const DefaultRequestDelay = 100 * time.Millisecond
+
+// DefaultOptions returns the options used when nothing else is configured
+//
+// The defaults reuse local files with at least 70% word overlap and fetch
+// examples one at a time, at most one request per DefaultRequestDelay.
+func DefaultOptions() Options {
+	return Options{
+		Threshold:      0.7,
+		MinWords:       naming.DefaultMinWords,
+		Concurrency:    1,
+		RequestDelay:   DefaultRequestDelay,
+		MinContentSize: DefaultMinContentSize,
+		ListingURL:     DefaultListingURL,
+		RawBaseURL:     DefaultRawBaseURL,
+	}
+}
+
+// Source records where the content of an Example came from
+type Source int
+
+const (
+	// Downloaded means the content was fetched from upstream in this run
+	Downloaded Source = iota
+	// Cached means a local file was revalidated with its ETag and upstream had not changed
+	Cached
+	// LocalMatch means a local file matched by word overlap was used because
+	// upstream could not be reached to revalidate it
+	LocalMatch
+	// LocalRepo means the content was read from a local gobyexample clone (Options.RepoDir)
+	LocalRepo
+	// Markdown means the content was converted from the user's own Markdown file
+	Markdown
+)
+
+// String returns the lower-case name of the source, e.g. for log output
+func (s Source) String() string {
+	switch s {
+	case Downloaded:
+		return "downloaded"
+	case Cached:
+		return "cached"
+	case LocalMatch:
+		return "local match"
+	case LocalRepo:
+		return "local repository"
+	case Markdown:
+		return "markdown"
+	default:
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+}
+
 // Example represents a Go by Example with its title, content, and filename
 //
 // This struct holds the metadata and content for a single Go programming example.
 // It's used throughout the application to represent examples that have been
 // downloaded from GitHub or found in existing local files.
 type Example struct {
-	Title   string // The human-readable title of the example
-	Content string // The HTML content of the example
-	File    string // The sanitized filename for the example
+	Title     string // The human-readable title of the example
+	Content   string // The HTML content of the example
+	File      string // The sanitized filename for the example
+	SourceURL string // The upstream URL the example content is published at
+	Source    Source // Where the content came from in this run
+
+	// ContentHash is the SHA-256 hex digest of Content as fetched, see
+	// manifest.HashContent; set by GetGitHubFiles
+	ContentHash string
+
+	// LastModified is when the content last changed, from the Last-Modified
+	// header of the download or the modification time of a local clone's
+	// file; zero if unknown
+	LastModified time.Time
 }
 
 // GetExampleFilesFromGitHub fetches the directory listing from GitHub and extracts example files
@@ -54,7 +267,7 @@ type Example struct {
 // This function performs the following operations:
 // 1. Makes an HTTP request to the GitHub repository page
 // 2. Parses the embedded JSON data that GitHub uses to populate the file browser
-// 3. Filters the files to include only example files (excluding assets like CSS, JS, images)
+// 3. Filters the files to include only example files (excluding assets and non-example pages)
 // 4. Returns a sorted list of example filenames
 //
 // The function handles GitHub's specific HTML structure and embedded JSON format
@@ -62,7 +275,8 @@ type Example struct {
 //
 // Returns:
 //   - []string: A slice of example filenames
-//   - error: Any error that occurred during the process
+//   - error: Any error that occurred during the process; it wraps
+//     ErrListingUnavailable or ErrNoExamplesFound
 //
 // Example:
 //
@@ -72,37 +286,92 @@ type Example struct {
 //	}
 //	fmt.Printf("Found %d example files\n", len(files))
 func GetExampleFilesFromGitHub() ([]string, error) {
+	return GetExampleFilesFromListing(DefaultListingURL)
+}
+
+// GetExampleFilesFromListing works like GetExampleFilesFromGitHub but reads
+// the directory listing from the given GitHub tree page URL
+//
+// Parameters:
+//   - url: The URL of the GitHub page listing the example files
+//
+// Returns:
+//   - []string: A slice of example filenames
+//   - error: Any error that occurred during the process
+func GetExampleFilesFromListing(url string) ([]string, error) {
 	// Fetch the directory listing from GitHub
-	url := "https://github.com/mmcgrana/gobyexample/tree/master/public"
-	fmt.Printf("[DEBUG] Fetching directory listing from: %s\n", url)
-	resp, err := http.Get(url)
+	logger.Debug("Fetching directory listing", "url", url)
+	req, err := newRequest(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrListingUnavailable, err)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch directory listing: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrListingUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("%w: %w", ErrListingUnavailable, newHTTPError(url, resp))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %v", ErrListingUnavailable, err)
 	}
 
-	content := string(body)
+	exampleFiles, truncated, err := parseEmbeddedJSON(string(body))
+	if err != nil {
+		return nil, err
+	}
 
+	// GitHub truncates the tree page of large directories; the REST API
+	// lists them completely
+	if truncated {
+		exampleFiles = completeTruncatedListing(url, exampleFiles)
+	}
+
+	if len(exampleFiles) == 0 {
+		return nil, fmt.Errorf("%w: the listing at %s contains no example files", ErrNoExamplesFound, url)
+	}
+
+	sort.Strings(exampleFiles)
+	logger.Debug("Found example files in embedded JSON", "count", len(exampleFiles))
+	return exampleFiles, nil
+}
+
+// embeddedDataMarker opens the JSON block GitHub embeds in its tree pages
+const embeddedDataMarker = `<script type="application/json" data-target="react-app.embeddedData">`
+
+// parseEmbeddedJSON extracts the example names from a GitHub tree page
+//
+// GitHub renders directory listings client-side from a JSON block embedded
+// in the page. Only files are considered; assets and names that do not look
+// like examples are dropped.
+//
+// Large directories are truncated by GitHub. This is detected from the
+// payload's truncation flags or from a total count exceeding the number of
+// listed items.
+//
+// Parameters:
+//   - page: The HTML of the GitHub tree page
+//
+// Returns:
+//   - []string: The example names in listing order; empty if the listing has none
+//   - bool: true if GitHub truncated the listing, i.e. the names are incomplete
+//   - error: An error wrapping ErrNoExamplesFound if the block is missing or malformed
+func parseEmbeddedJSON(page string) ([]string, bool, error) {
 	// Find the embedded JSON block
-	jsonStart := strings.Index(content, `<script type="application/json" data-target="react-app.embeddedData">`)
+	jsonStart := strings.Index(page, embeddedDataMarker)
 	if jsonStart == -1 {
-		return nil, fmt.Errorf("could not find embedded JSON block in GitHub page")
+		return nil, false, fmt.Errorf("%w: could not find embedded JSON block in GitHub page", ErrNoExamplesFound)
 	}
-	jsonStart += len(`<script type="application/json" data-target="react-app.embeddedData">`)
-	jsonEnd := strings.Index(content[jsonStart:], "</script>")
+	jsonStart += len(embeddedDataMarker)
+	jsonEnd := strings.Index(page[jsonStart:], "</script>")
 	if jsonEnd == -1 {
-		return nil, fmt.Errorf("could not find end of embedded JSON block in GitHub page")
+		return nil, false, fmt.Errorf("%w: could not find end of embedded JSON block in GitHub page", ErrNoExamplesFound)
 	}
-	jsonStr := content[jsonStart : jsonStart+jsonEnd]
+	jsonStr := page[jsonStart : jsonStart+jsonEnd]
 
 	// Parse the JSON
 	var embedded struct {
@@ -112,28 +381,171 @@ func GetExampleFilesFromGitHub() ([]string, error) {
 					Name        string `json:"name"`
 					ContentType string `json:"contentType"`
 				} `json:"items"`
+				TotalCount int  `json:"totalCount"`
+				Truncated  bool `json:"truncated"`
+				HasMore    bool `json:"hasMore"`
 			} `json:"tree"`
 		} `json:"payload"`
 	}
 	if err := json.Unmarshal([]byte(jsonStr), &embedded); err != nil {
-		return nil, fmt.Errorf("failed to parse embedded JSON: %v", err)
+		return nil, false, fmt.Errorf("%w: failed to parse embedded JSON: %v", ErrNoExamplesFound, err)
 	}
 
+	tree := embedded.Payload.Tree
 	var exampleFiles []string
-	for _, item := range embedded.Payload.Tree.Items {
-		if item.ContentType == "file" &&
-			!strings.HasSuffix(item.Name, ".html") &&
-			!strings.HasSuffix(item.Name, ".js") &&
-			!strings.HasSuffix(item.Name, ".css") &&
-			!strings.HasSuffix(item.Name, ".png") &&
-			!strings.HasSuffix(item.Name, ".ico") {
+	for _, item := range tree.Items {
+		if item.ContentType == "file" && isListedExample(item.Name) {
 			exampleFiles = append(exampleFiles, item.Name)
 		}
 	}
 
-	sort.Strings(exampleFiles)
-	fmt.Printf("[DEBUG] Found %d example files from embedded JSON.\n", len(exampleFiles))
-	return exampleFiles, nil
+	truncated := tree.Truncated || tree.HasMore || tree.TotalCount > len(tree.Items)
+	return exampleFiles, truncated, nil
+}
+
+// isListedExample reports whether a file of a directory listing is an
+// example page; skipped names that are not assets are logged
+func isListedExample(name string) bool {
+	if hasAssetExtension(name) {
+		return false
+	}
+	if !IsExampleName(name) {
+		logger.Info(name, logging.Tag("NOT AN EXAMPLE"))
+		return false
+	}
+	return true
+}
+
+// completeTruncatedListing replaces a truncated tree page listing with the
+// complete listing from the GitHub REST API
+//
+// If the listing URL has no REST equivalent or the API request fails, the
+// partial listing is kept so the build can still go ahead; a warning makes
+// the missing examples visible.
+//
+// Parameters:
+//   - listingURL: The URL of the truncated tree page
+//   - partial: The example names found on the tree page
+//
+// Returns:
+//   - []string: The complete listing, or partial if it could not be completed
+func completeTruncatedListing(listingURL string, partial []string) []string {
+	logger.Warn("GitHub truncated the example listing, some examples may be missing", "url", listingURL, "listed", len(partial))
+
+	apiURL, ok := contentsAPIURL(listingURL)
+	if !ok {
+		logger.Warn("No REST API equivalent for the listing URL, continuing with the partial listing", "url", listingURL)
+		return partial
+	}
+
+	complete, err := getExampleFilesFromAPI(apiURL)
+	if err != nil {
+		logger.Warn("Could not list examples via the REST API, continuing with the partial listing", "err", err)
+		return partial
+	}
+	logger.Info(fmt.Sprintf("%d examples listed via the REST API", len(complete)), logging.Tag("LISTING COMPLETED"))
+	return complete
+}
+
+// hasAssetExtension reports whether a listed file is an asset (HTML, JS,
+// CSS or image) rather than an extensionless example page
+func hasAssetExtension(name string) bool {
+	for _, ext := range []string{".html", ".js", ".css", ".png", ".ico"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// NonExampleNames lists files of the published site that are not examples
+//
+// Names are compared case-insensitively.
+var NonExampleNames = map[string]bool{
+	"index":      true,
+	"404":        true,
+	"license":    true,
+	"makefile":   true,
+	"readme":     true,
+	"cname":      true,
+	"robots.txt": true,
+}
+
+// exampleSlugPattern matches the lower-case, hyphenated names of example
+// pages, e.g. "hello-world" or "exec'ing-processes"
+var exampleSlugPattern = regexp.MustCompile(`^[a-z0-9]+(?:['-][a-z0-9]+)*$`)
+
+// IsExampleName reports whether a listed file looks like an example page
+//
+// A name qualifies if it is an example slug and not in NonExampleNames.
+// Repository metadata like LICENSE or Makefile and site pages like index
+// or 404 are rejected, so they never end up in the TOC.
+//
+// Parameters:
+//   - name: The filename from the directory listing
+//
+// Returns:
+//   - bool: true if the file should be treated as an example
+func IsExampleName(name string) bool {
+	if NonExampleNames[strings.ToLower(name)] {
+		return false
+	}
+	return exampleSlugPattern.MatchString(name)
+}
+
+// htmlMarkerPattern matches the tags every example page contains
+var htmlMarkerPattern = regexp.MustCompile(`(?i)<(?:!doctype html|html|body)[\s>]`)
+
+// checkContent rejects HTML that cannot be a real example page
+//
+// Near-empty bodies and non-HTML responses (e.g. a soft-404 or a plain text
+// error message) would otherwise render as blank pages in the book.
+//
+// Parameters:
+//   - content: The HTML content of the example
+//   - minSize: The minimum size in bytes; 0 disables the size check
+//
+// Returns:
+//   - error: Why the content was rejected, or nil if it looks plausible
+func checkContent(content string, minSize int) error {
+	if minSize > 0 && len(content) < minSize {
+		return fmt.Errorf("content is only %d bytes (minimum %d)", len(content), minSize)
+	}
+	if !htmlMarkerPattern.MatchString(content) {
+		return fmt.Errorf("content is not an HTML document")
+	}
+	return nil
+}
+
+// FilterExampleFiles selects example filenames by pattern
+//
+// A filename is kept if it matches include (or include is nil) and does not
+// match exclude (or exclude is nil). When a filename matches both patterns,
+// exclude wins. The order of the input is preserved.
+//
+// Parameters:
+//   - files: The example filenames to filter
+//   - include: Pattern a filename must match to be kept; nil keeps all
+//   - exclude: Pattern that drops a matching filename; nil drops none
+//
+// Returns:
+//   - []string: The filenames that passed the filter
+//
+// Example:
+//
+//	files = FilterExampleFiles(files, regexp.MustCompile(`channel|goroutine`), nil)
+func FilterExampleFiles(files []string, include, exclude *regexp.Regexp) []string {
+	var kept []string
+	for _, name := range files {
+		if include != nil && !include.MatchString(name) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(name) {
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept
 }
 
 // Helper functions needed by getGitHubFiles
@@ -142,39 +554,76 @@ func GetExampleFilesFromGitHub() ([]string, error) {
 //
 // This is a helper function that performs HTTP GET requests and returns
 // the response body as a string. It includes proper error handling for
-// HTTP status codes and network errors.
-func downloadFile(url string) (string, error) {
-	resp, err := http.Get(url)
+// HTTP status codes and network errors; an unexpected status is returned as
+// an *HTTPError carrying the URL and the start of the response body, and a
+// response whose type is not in accept as a *ContentTypeError.
+func downloadFile(url string, accept []string) (string, error) {
+	content, _, _, _, err := downloadFileIfChanged(url, "", accept)
+	return content, err
+}
+
+// downloadToFile downloads content from a URL and streams it into a file
+//
+// Unlike downloadFile, the response body is copied to disk as it arrives
+// instead of being held in memory, and it is written as raw bytes, which is
+// what binary files like images need. The body is written to a temporary
+// file in the same directory that is renamed to dest once complete, so a
+// failed download never leaves a truncated file behind; existing assets are
+// kept by later runs, so a truncated one would stay broken.
+//
+// Errors are reported like by downloadFile: an unexpected status as an
+// *HTTPError and a response whose type is not in accept as a
+// *ContentTypeError.
+func downloadToFile(url, dest string, accept []string) error {
+	req, err := newRequest(url)
 	if err != nil {
-		return "", err
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return newHTTPError(url, resp)
+	}
+	if err := checkContentType(url, resp.Header.Get("Content-Type"), accept); err != nil {
+		return err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*.tmp")
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
 
-	return string(body), nil
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp creates the file readable only by the owner
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
 }
 
 // downloadAsset downloads a file from a URL and saves it to the specified directory
 //
-// This helper function combines downloadFile with file writing functionality.
-// It's used to download assets like CSS, JavaScript, and image files that
-// are required for the examples to display correctly.
+// This helper function streams the file to disk with downloadToFile. It's
+// used to download assets like CSS, JavaScript, and image files that are
+// required for the examples to display correctly. The accepted content
+// types follow from the file extension, see assetContentTypes.
 func downloadAsset(url, filename, outputDir string) error {
-	content, err := downloadFile(url)
+	err := downloadToFile(url, filepath.Join(outputDir, filename), assetContentTypes[filepath.Ext(filename)])
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to download %s: %w", filename, err)
 	}
-
-	filepath := filepath.Join(outputDir, filename)
-	return os.WriteFile(filepath, []byte(content), 0644)
+	return nil
 }
 
 // sanitizeFilename converts a title to a safe filename
@@ -196,20 +645,38 @@ func sanitizeFilename(title string) string {
 // This is the main function of the package that orchestrates the entire process
 // of downloading Go by Example content. It performs the following steps:
 //
-// 1. Downloads required assets (CSS, JS, images) from the GitHub repository
+// 1. Downloads the missing assets (CSS, JS, images) from the GitHub repository
 // 2. Fetches the list of available example files
 // 3. For each example file:
 //   - Checks if a corresponding HTML file already exists locally
 //   - Uses word-based matching to find existing files with similar names
+//   - Revalidates a matched file with its stored ETag, or downloads it once
+//     if none is stored yet
 //   - Downloads the example content if no match is found
 //   - Creates Example structs with the content and metadata
 //
+// Assets that already exist are kept unless opts.RefreshAssets or opts.Force
+// is set.
+//
 // The function includes intelligent caching - if an HTML file with a similar
 // name already exists, it will use that instead of re-downloading the content.
 // This is determined using the naming package's word overlap functionality.
+// A matched file is revalidated with a conditional request using the ETag
+// recorded on a previous run, so upstream changes are picked up exactly; the
+// ETags are kept in ETagFileName in the output directory. A matched file
+// without a recorded ETag is downloaded once instead, which records it.
+//
+// Examples are processed by opts.Concurrency workers in parallel; the
+// result is sorted by title, so the order does not depend on scheduling.
+// Requests of all workers together are spaced at least opts.RequestDelay
+// apart to be nice to the server.
+// opts.Include and opts.Exclude filter the listing before anything is
+// downloaded, and with opts.Limit set only the first of the remaining
+// examples are fetched at all, which keeps test builds fast.
 //
 // Parameters:
 //   - outputDir: The directory where files should be saved
+//   - opts: Options controlling matching and concurrency (see DefaultOptions)
 //
 // Returns:
 //   - []Example: A slice of Example structs containing all the examples
@@ -217,116 +684,276 @@ func sanitizeFilename(title string) string {
 //
 // Example:
 //
-//	examples, err := GetGitHubFiles("./output")
+//	examples, err := GetGitHubFiles("./output", DefaultOptions())
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
 //	fmt.Printf("Processed %d examples\n", len(examples))
-func GetGitHubFiles(outputDir string) ([]Example, error) {
-	// Download required assets first
-	fmt.Println("[INFO] Downloading assets...")
+func GetGitHubFiles(outputDir string, opts Options) ([]Example, error) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.ListingURL == "" {
+		opts.ListingURL = DefaultListingURL
+	}
+	if opts.RawBaseURL == "" {
+		opts.RawBaseURL = DefaultRawBaseURL
+	}
+	rawBase := strings.TrimSuffix(opts.RawBaseURL, "/")
 
-	assets := []struct {
-		url      string
-		filename string
-	}{
-		{"https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public/site.css", "site.css"},
-		{"https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public/site.js", "site.js"},
-		{"https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public/play.png", "play.png"},
-		{"https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public/clipboard.png", "clipboard.png"},
+	// Download required assets first
+	if opts.RepoDir != "" {
+		logger.Info("Copying assets from local repository...")
+	} else {
+		logger.Info("Downloading assets...")
 	}
 
+	assets := []string{"site.css", "site.js", "play.png", "clipboard.png"}
+
+	pace := ratelimit.Every(opts.RequestDelay)
+
 	for _, asset := range assets {
-		fmt.Printf("[DOWNLOADING] %s\n", asset.filename)
-		err := downloadAsset(asset.url, asset.filename, outputDir)
+		if opts.RepoDir != "" {
+			if err := copyRepoFile(opts.RepoDir, asset, outputDir); err != nil {
+				logger.Warn("Failed to copy asset from local repository", "file", asset, "err", err)
+			}
+			continue
+		}
+
+		if !opts.RefreshAssets && !opts.Force {
+			if _, err := os.Stat(filepath.Join(outputDir, asset)); err == nil {
+				logger.Info(asset, logging.Tag("USING EXISTING"))
+				continue
+			}
+		}
+
+		pace.Wait()
+		logger.Info(asset, logging.Tag("DOWNLOADING"))
+		err := downloadAsset(rawBase+"/"+asset, asset, outputDir)
 		if err != nil {
-			log.Printf("[WARNING] Failed to download %s: %v", asset.filename, err)
+			logger.Warn("Failed to download asset", "file", asset, "err", err)
 		} else {
-			fmt.Printf("[DOWNLOADED] %s\n", asset.filename)
+			logger.Info(asset, logging.Tag("DOWNLOADED"))
 		}
 	}
 
-	// Dynamically fetch all available examples from GitHub
-	exampleFiles, err := GetExampleFilesFromGitHub()
+	// Dynamically fetch all available examples from GitHub or the local clone
+	var exampleFiles []string
+	var err error
+	if opts.RepoDir != "" {
+		exampleFiles, err = GetExampleFilesFromRepo(opts.RepoDir)
+	} else {
+		exampleFiles, err = GetExampleFilesFromListing(opts.ListingURL)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get example files from GitHub: %v", err)
+		return nil, fmt.Errorf("failed to list example files: %w", err)
+	}
+	if opts.Include != nil || opts.Exclude != nil {
+		total := len(exampleFiles)
+		exampleFiles = FilterExampleFiles(exampleFiles, opts.Include, opts.Exclude)
+		logger.Info(fmt.Sprintf("Filter selected %d of %d examples", len(exampleFiles), total))
+	}
+	if opts.Limit > 0 && opts.Limit < len(exampleFiles) {
+		logger.Info(fmt.Sprintf("Limiting build to the first %d of %d examples", opts.Limit, len(exampleFiles)))
+		exampleFiles = exampleFiles[:opts.Limit]
 	}
 
-	var examples []Example
-	fmt.Printf("[INFO] Processing %d examples...\n", len(exampleFiles))
-
-	for _, filename := range exampleFiles {
-		// First, try to find existing HTML files that might match this example
-		// We'll use word-based matching to find corresponding files
-		var htmlContent string
-		var title string
-		var sanitizedFilename string
-		var foundExisting bool
-
-		// Extract words from the original filename
-		originalWords := naming.ExtractWords(filename)
-
-		// Scan existing HTML files to find a match
-		entries, err := os.ReadDir(outputDir)
-		if err == nil {
-			for _, entry := range entries {
-				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".html") {
-					// Extract words from the existing HTML filename
-					existingWords := naming.ExtractWords(strings.TrimSuffix(entry.Name(), ".html"))
-
-					// Check if there's significant word overlap
-					if naming.WordOverlap(originalWords, existingWords) >= 0.7 { // 70% overlap threshold
-						// Found a match, read the HTML file
-						htmlPath := filepath.Join(outputDir, entry.Name())
-						content, err := os.ReadFile(htmlPath)
-						if err != nil {
-							log.Printf("[WARNING] Failed to read existing HTML file %s: %v", entry.Name(), err)
-							continue
-						}
-						htmlContent = string(content)
-						title = strings.TrimSuffix(entry.Name(), ".html")
-						sanitizedFilename = strings.TrimSuffix(entry.Name(), ".html")
-						foundExisting = true
-						fmt.Printf("[USING EXISTING] %s (as %s.html)\n", title, sanitizedFilename)
-						break
-					}
+	etagCache, err := LoadETagCache(filepath.Join(outputDir, ETagFileName))
+	if err != nil {
+		logger.Warn("Could not load ETag cache", "err", err)
+		etagCache = &ETagCache{path: filepath.Join(outputDir, ETagFileName), ETags: make(map[string]string)}
+	}
+
+	// Read the existing HTML files once; fetching does not write any
+	localFiles := indexLocalFiles(outputDir, opts)
+
+	logger.Info(fmt.Sprintf("Processing %d examples...", len(exampleFiles)))
+
+	// Fetch examples with a bounded number of workers; each result keeps its slot
+	results := make([]*Example, len(exampleFiles))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, filename := range exampleFiles {
+		sem <- struct{}{}
+		if opts.Context != nil && opts.Context.Err() != nil {
+			<-sem
+			logger.Warn("Interrupted, not fetching the remaining examples", "remaining", len(exampleFiles)-i)
+			break
+		}
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ex, err := fetchExample(filename, outputDir, localFiles, opts, etagCache, pace)
+			if err != nil {
+				logger.Warn("Skipping example", "file", filename, "err", err)
+				if opts.OnFailure != nil {
+					opts.OnFailure(filename, err)
 				}
+				return
 			}
+			ex.ContentHash = manifest.HashContent(ex.Content)
+			results[i] = &ex
+		}(i, filename)
+	}
+	wg.Wait()
+
+	var examples []Example
+	for _, ex := range results {
+		if ex != nil {
+			examples = append(examples, *ex)
 		}
+	}
 
-		if !foundExisting {
-			// Download HTML content from GitHub
-			url := fmt.Sprintf("https://raw.githubusercontent.com/mmcgrana/gobyexample/master/public/%s", filename)
-			fmt.Printf("[DOWNLOADING] %s\n", filename)
+	if err := etagCache.Save(); err != nil {
+		logger.Warn("Could not save ETag cache", "err", err)
+	}
 
-			htmlContent, err = downloadFile(url)
+	sort.Slice(examples, func(i, j int) bool {
+		return examples[i].Title < examples[j].Title
+	})
+
+	return examples, nil
+}
+
+// indexLocalFiles indexes the HTML files in outputDir for matching them to
+// the upstream examples
+//
+// Returns:
+//   - *naming.Index: The index of the HTML filenames; nil if the examples
+//     are read from a local clone, opts.Force is set or the directory
+//     cannot be read
+func indexLocalFiles(outputDir string, opts Options) *naming.Index {
+	if opts.RepoDir != "" || opts.Force {
+		return nil
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil
+	}
+
+	var htmlFiles []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".html") {
+			htmlFiles = append(htmlFiles, entry.Name())
+		}
+	}
+	return naming.NewIndex(htmlFiles)
+}
+
+// fetchExample resolves the content of a single upstream example file
+//
+// It first looks up an existing local HTML file with a similar name (word
+// overlap of at least opts.Threshold, see naming.Matches) in localFiles,
+// revalidating it with its stored ETag or downloading it once if none is
+// stored, and downloads the example otherwise. With a nil localFiles, as with opts.Force set, the
+// example is always downloaded.
+//
+// Every request to upstream first waits for pace, so the request rate stays
+// within opts.RequestDelay across all workers.
+//
+// Returns:
+//   - Example: The resolved example
+//   - error: Why the example could not be resolved and must be skipped
+func fetchExample(filename, outputDir string, localFiles *naming.Index, opts Options, etagCache *ETagCache, pace *ratelimit.Limiter) (Example, error) {
+	if opts.RepoDir != "" {
+		return fetchRepoExample(filename, opts)
+	}
+
+	// First, try to find existing HTML files that might match this example
+	// We'll use word-based matching to find corresponding files
+	var htmlContent string
+	var title string
+	var sanitizedFilename string
+	var foundExisting bool
+
+	// Look up existing HTML files with significant word overlap
+	if localFiles != nil {
+		if logger.Enabled(context.Background(), slog.LevelDebug) {
+			candidate, overlap := localFiles.Best(filename, opts.Metric)
+			logger.Debug("Best local match", "file", filename, "candidate", candidate,
+				"overlap", fmt.Sprintf("%.2f", overlap), "threshold", opts.Threshold)
+		}
+		for _, name := range localFiles.Matches(filename, opts.Threshold, opts.MinWords, opts.Metric) {
+			// Found a match, read the HTML file
+			htmlPath := filepath.Join(outputDir, name)
+			content, err := os.ReadFile(htmlPath)
 			if err != nil {
-				log.Printf("[WARNING] Failed to download %s: %v", filename, err)
+				logger.Warn("Failed to read existing HTML file", "file", name, "err", err)
 				continue
 			}
+			htmlContent = string(content)
+			title = strings.TrimSuffix(name, ".html")
+			sanitizedFilename = strings.TrimSuffix(name, ".html")
+			foundExisting = true
+			logger.Info(fmt.Sprintf("%s (as %s.html)", title, sanitizedFilename), logging.Tag("USING EXISTING"))
+			break
+		}
+	}
 
-			// Use the URL filename for both title and sanitized filename
-			// This ensures consistency and avoids HTML parsing issues
-			title = filename
-			sanitizedFilename = sanitizeFilename(filename)
-			fmt.Printf("[DOWNLOADED] %s -> %s\n", title, sanitizedFilename)
+	url := strings.TrimSuffix(opts.RawBaseURL, "/") + "/" + filename
+	source := LocalMatch
+	var lastModified time.Time
+
+	// Revalidate a matched local file with the ETag recorded for it. Files
+	// without one, e.g. of a run before ETags were recorded, are downloaded
+	// once, which records the ETag for the next run
+	if foundExisting {
+		_, known := etagCache.Get(url)
+		htmlPath := filepath.Join(outputDir, sanitizedFilename+".html")
+		pace.Wait()
+		content, reused, modified, err := DownloadCached(url, htmlPath, etagCache, ExampleContentTypes)
+		switch {
+		case err != nil:
+			logger.Warn("Could not revalidate, using local copy", "file", filename, "err", err)
+		case reused:
+			source = Cached
+			lastModified = modified
+			logger.Info(filename, logging.Tag("NOT MODIFIED"))
+		case !known:
+			source = Downloaded
+			lastModified = modified
+			htmlContent = content
+			logger.Info(filename+" (no ETag recorded yet)", logging.Tag("CHECKED"))
+		default:
+			source = Downloaded
+			lastModified = modified
+			htmlContent = content
+			logger.Info(filename+" (upstream content changed)", logging.Tag("UPDATED"))
 		}
+	}
 
-		examples = append(examples, Example{
-			Title:   title,
-			Content: htmlContent,
-			File:    sanitizedFilename,
-		})
+	if !foundExisting {
+		// Download HTML content from GitHub
+		logger.Info(filename, logging.Tag("DOWNLOADING"))
 
-		// Small delay to be nice to the server (only when downloading)
-		if !foundExisting {
-			time.Sleep(100 * time.Millisecond)
+		pace.Wait()
+		var err error
+		htmlContent, _, lastModified, err = DownloadCached(url, "", etagCache, ExampleContentTypes)
+		if err != nil {
+			return Example{}, fmt.Errorf("download failed: %v", err)
 		}
+
+		// Use the URL filename for both title and sanitized filename
+		// This ensures consistency and avoids HTML parsing issues
+		title = filename
+		sanitizedFilename = sanitizeFilename(filename)
+		source = Downloaded
+		logger.Info(fmt.Sprintf("%s -> %s", title, sanitizedFilename), logging.Tag("DOWNLOADED"))
 	}
 
-	sort.Slice(examples, func(i, j int) bool {
-		return examples[i].Title < examples[j].Title
-	})
+	if err := checkContent(htmlContent, opts.MinContentSize); err != nil {
+		return Example{}, fmt.Errorf("implausible content: %v", err)
+	}
 
-	return examples, nil
+	return Example{
+		Title:        title,
+		Content:      htmlContent,
+		File:         sanitizedFilename,
+		SourceURL:    url,
+		Source:       source,
+		LastModified: lastModified,
+	}, nil
 }
@@ -0,0 +1,472 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-by-example-book/internal/logging"
+)
+
+func TestMain(m *testing.M) {
+	SetLogger(logging.New(io.Discard, slog.LevelError))
+	os.Exit(m.Run())
+}
+
+// pngBytes is a 1x1 PNG; its header contains bytes that are not valid UTF-8
+// and a CR LF pair, which a text round trip would corrupt
+var pngBytes = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+	0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0xf8, 0xcf, 0xc0, 0xf0,
+	0x1f, 0x00, 0x05, 0x00, 0x01, 0xff, 0x89, 0x99, 0x3d, 0x1d, 0x00, 0x00,
+	0x00, 0x00, 0x49, 0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+func TestDownloadAssetPNG(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngBytes)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := downloadAsset(server.URL+"/play.png", "play.png", dir); err != nil {
+		t.Fatalf("downloadAsset: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "play.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pngBytes) {
+		t.Errorf("downloaded PNG differs:\n got % x\nwant % x", got, pngBytes)
+	}
+
+	// Only the asset is left behind, no temporary file
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("output directory has %d entries, want only play.png", len(entries))
+	}
+}
+
+func TestDownloadAssetWrongType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>Not Found</body></html>"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := downloadAsset(server.URL+"/play.png", "play.png", dir); err == nil {
+		t.Fatal("downloadAsset accepted an HTML page as PNG")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "play.png")); !os.IsNotExist(err) {
+		t.Errorf("rejected asset was written: %v", err)
+	}
+}
+
+// listingItem is a file or directory of a fake GitHub tree page
+type listingItem struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+}
+
+// listingPage renders a GitHub tree page embedding the given items
+func listingPage(t *testing.T, items []listingItem) string {
+	t.Helper()
+	var embedded struct {
+		Payload struct {
+			Tree struct {
+				Items      []listingItem `json:"items"`
+				TotalCount int           `json:"totalCount"`
+			} `json:"tree"`
+		} `json:"payload"`
+	}
+	embedded.Payload.Tree.Items = items
+	embedded.Payload.Tree.TotalCount = len(items)
+	data, err := json.Marshal(embedded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "<!DOCTYPE html><html><body>" + embeddedDataMarker + string(data) + "</script></body></html>"
+}
+
+// examplePage returns a plausible example page, larger than DefaultMinContentSize
+func examplePage(name string) string {
+	return "<!DOCTYPE html><html><body><h2>" + name + "</h2>" +
+		strings.Repeat("<pre>fmt.Println(\""+name+"\")</pre>\n", 50) + "</body></html>"
+}
+
+// newFakeUpstream serves a GitHub tree page at /tree listing the examples
+// and the site's assets, and the raw files below /raw/; examples listed in
+// missing are listed but answer 404. The ETag of an example is its quoted
+// name, and a request sending it as If-None-Match gets 304 Not Modified.
+func newFakeUpstream(t *testing.T, examples []string, missing ...string) *httptest.Server {
+	t.Helper()
+	items := []listingItem{
+		{"site.css", "file"}, {"site.js", "file"}, {"play.png", "file"},
+		{"clipboard.png", "file"}, {"index.html", "file"}, {"CNAME", "file"},
+		{"images", "directory"},
+	}
+	for _, name := range append(slices.Clone(examples), missing...) {
+		items = append(items, listingItem{name, "file"})
+	}
+	page := listingPage(t, items)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tree", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/raw/{file}", func(w http.ResponseWriter, r *http.Request) {
+		file := r.PathValue("file")
+		switch {
+		case file == "site.css":
+			w.Header().Set("Content-Type", "text/css")
+			io.WriteString(w, "body { margin: 0 }")
+		case file == "site.js":
+			w.Header().Set("Content-Type", "text/javascript")
+			io.WriteString(w, "function copyCode() {}")
+		case strings.HasSuffix(file, ".png"):
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngBytes)
+		case slices.Contains(examples, file):
+			etag := fmt.Sprintf("%q", file)
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, examplePage(file))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakeOptions returns options fetching from the fake upstream without delay
+func fakeOptions(server *httptest.Server) Options {
+	opts := DefaultOptions()
+	opts.RequestDelay = 0
+	opts.ListingURL = server.URL + "/tree"
+	opts.RawBaseURL = server.URL + "/raw/"
+	return opts
+}
+
+func TestGetExampleFilesFromListing(t *testing.T) {
+	server := newFakeUpstream(t, []string{"values", "hello-world", "closures"})
+
+	files, err := GetExampleFilesFromListing(server.URL + "/tree")
+	if err != nil {
+		t.Fatalf("GetExampleFilesFromListing: %v", err)
+	}
+	// Sorted, without assets, non-examples and directories
+	want := []string{"closures", "hello-world", "values"}
+	if !slices.Equal(files, want) {
+		t.Errorf("listed %q, want %q", files, want)
+	}
+}
+
+func TestGetExampleFilesFromListingErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/changed", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><body>A redesigned page</body></html>")
+	})
+	mux.HandleFunc("/assets-only", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, listingPage(t, []listingItem{{"site.css", "file"}}))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	_, err := GetExampleFilesFromListing(server.URL + "/down")
+	if !errors.Is(err, ErrListingUnavailable) {
+		t.Errorf("unavailable listing: got %v, want ErrListingUnavailable", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unavailable listing: got %v, want an HTTPError with status 503", err)
+	}
+
+	for _, path := range []string{"/changed", "/assets-only"} {
+		if _, err := GetExampleFilesFromListing(server.URL + path); !errors.Is(err, ErrNoExamplesFound) {
+			t.Errorf("%s: got %v, want ErrNoExamplesFound", path, err)
+		}
+	}
+}
+
+func TestGetGitHubFiles(t *testing.T) {
+	server := newFakeUpstream(t, []string{"values", "hello-world"}, "gone")
+	opts := fakeOptions(server)
+	var failed []string
+	opts.OnFailure = func(filename string, err error) {
+		failed = append(failed, filename)
+	}
+
+	dir := t.TempDir()
+	examples, err := GetGitHubFiles(dir, opts)
+	if err != nil {
+		t.Fatalf("GetGitHubFiles: %v", err)
+	}
+
+	if len(examples) != 2 {
+		t.Fatalf("got %d examples, want 2", len(examples))
+	}
+	for i, want := range []string{"hello-world", "values"} {
+		ex := examples[i]
+		if ex.Title != want {
+			t.Errorf("example %d is %q, want %q", i, ex.Title, want)
+		}
+		if ex.Content != examplePage(want) {
+			t.Errorf("%s: content differs from the served page", want)
+		}
+		if ex.Source != Downloaded {
+			t.Errorf("%s: source %v, want %v", want, ex.Source, Downloaded)
+		}
+		if ex.SourceURL != server.URL+"/raw/"+want {
+			t.Errorf("%s: source URL %s", want, ex.SourceURL)
+		}
+		if ex.ContentHash == "" {
+			t.Errorf("%s: no content hash", want)
+		}
+	}
+	if examples[0].File != "hello_world" {
+		t.Errorf("file of hello-world is %q, want hello_world", examples[0].File)
+	}
+
+	// The missing example is skipped and reported
+	if !slices.Equal(failed, []string{"gone"}) {
+		t.Errorf("OnFailure called for %q, want [gone]", failed)
+	}
+
+	// The assets are saved and the ETags recorded for the next run
+	for _, asset := range []string{"site.css", "site.js", "play.png", "clipboard.png"} {
+		if _, err := os.Stat(filepath.Join(dir, asset)); err != nil {
+			t.Errorf("asset %s not saved: %v", asset, err)
+		}
+	}
+	cache, err := LoadETagCache(filepath.Join(dir, ETagFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag, ok := cache.Get(server.URL + "/raw/values"); !ok || etag != `"values"` {
+		t.Errorf("ETag of values is %q (%v), want \"values\"", etag, ok)
+	}
+}
+
+func TestGetGitHubFilesRevalidatesLocalMatches(t *testing.T) {
+	server := newFakeUpstream(t, []string{"values"})
+	opts := fakeOptions(server)
+
+	// A file of a run that recorded no ETags, older than upstream
+	dir := t.TempDir()
+	htmlPath := filepath.Join(dir, "values.html")
+	if err := os.WriteFile(htmlPath, []byte(examplePage("outdated")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an ETag the match is downloaded once, which records the ETag
+	examples, err := GetGitHubFiles(dir, opts)
+	if err != nil {
+		t.Fatalf("GetGitHubFiles: %v", err)
+	}
+	if len(examples) != 1 {
+		t.Fatalf("got %d examples, want 1", len(examples))
+	}
+	if ex := examples[0]; ex.Source != Downloaded || ex.Content != examplePage("values") {
+		t.Errorf("first run: source %v, upstream content %v; want the downloaded upstream content", ex.Source, ex.Content == examplePage("values"))
+	}
+	cache, err := LoadETagCache(filepath.Join(dir, ETagFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag, ok := cache.Get(server.URL + "/raw/values"); !ok || etag != `"values"` {
+		t.Errorf("the ETag of values was not recorded: %q (%v)", etag, ok)
+	}
+
+	// With the ETag the next run revalidates and keeps the local copy
+	if err := os.WriteFile(htmlPath, []byte(examplePage("values")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	examples, err = GetGitHubFiles(dir, opts)
+	if err != nil {
+		t.Fatalf("GetGitHubFiles: %v", err)
+	}
+	if ex := examples[0]; ex.Source != Cached || ex.Content != examplePage("values") {
+		t.Errorf("second run: source %v, want %v with the local content", ex.Source, Cached)
+	}
+}
+
+func TestGetGitHubFilesListingUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := GetGitHubFiles(t.TempDir(), fakeOptions(server))
+	if !errors.Is(err, ErrListingUnavailable) {
+		t.Errorf("got %v, want ErrListingUnavailable", err)
+	}
+}
+
+func TestSetHTTPTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		io.WriteString(w, examplePage("too-late"))
+	}))
+	defer server.Close()
+
+	SetHTTPTimeout(50 * time.Millisecond)
+	t.Cleanup(func() { SetHTTPTimeout(DefaultHTTPTimeout) })
+
+	start := time.Now()
+	_, err := downloadFile(server.URL+"/slow", ExampleContentTypes)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v despite the 50ms timeout", elapsed)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("got %v, want a timeout error", err)
+	}
+}
+
+func TestParseEmbeddedJSON(t *testing.T) {
+	wrap := func(json string) string {
+		return "<html><body>" + embeddedDataMarker + json + "</script></body></html>"
+	}
+	tree := func(fields string) string {
+		return wrap(`{"payload":{"tree":{` + fields + `}}}`)
+	}
+	items := `"items":[{"name":"values","contentType":"file"},{"name":"site.css","contentType":"file"},` +
+		`{"name":"hello-world","contentType":"file"},{"name":"images","contentType":"directory"}]`
+
+	tests := []struct {
+		name          string
+		page          string
+		want          []string
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{"well-formed", tree(items + `,"totalCount":4`), []string{"values", "hello-world"}, false, false},
+		{"no total count", tree(items), []string{"values", "hello-world"}, false, false},
+		{"truncated flag", tree(items + `,"truncated":true`), []string{"values", "hello-world"}, true, false},
+		{"has more", tree(items + `,"hasMore":true`), []string{"values", "hello-world"}, true, false},
+		{"total count exceeds items", tree(items + `,"totalCount":120`), []string{"values", "hello-world"}, true, false},
+		{"no items", tree(`"items":[]`), nil, false, false},
+		{"empty object", wrap(`{}`), nil, false, false},
+
+		{"empty page", "", nil, false, true},
+		{"no block", "<html><body>No listing here</body></html>", nil, false, true},
+		{"unterminated block", "<html>" + embeddedDataMarker + `{"payload":{}}`, nil, false, true},
+		{"empty block", wrap(""), nil, false, true},
+		{"malformed JSON", wrap(`{"payload":{"tree":{"items":[`), nil, false, true},
+		{"wrong types", tree(`"items":"values"`), nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated, err := parseEmbeddedJSON(tt.page)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoExamplesFound) {
+					t.Errorf("got error %v, want ErrNoExamplesFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestFilterExampleFiles(t *testing.T) {
+	files := []string{"channels", "closures", "goroutines", "channel-buffering", "values"}
+	tests := []struct {
+		name             string
+		include, exclude string
+		want             []string
+	}{
+		{"no filter", "", "", files},
+		{"include", "channel|goroutine", "", []string{"channels", "goroutines", "channel-buffering"}},
+		{"exclude", "", "^c", []string{"goroutines", "values"}},
+		{"exclude wins", "channel", "buffering", []string{"channels"}},
+		{"nothing left", "^x", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var include, exclude *regexp.Regexp
+			if tt.include != "" {
+				include = regexp.MustCompile(tt.include)
+			}
+			if tt.exclude != "" {
+				exclude = regexp.MustCompile(tt.exclude)
+			}
+			if got := FilterExampleFiles(files, include, exclude); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGitHubFilesFilterAndLimit(t *testing.T) {
+	server := newFakeUpstream(t, []string{"channels", "closures", "channel-buffering", "values"})
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"filter only", 0, []string{"channel-buffering", "channels"}},
+		{"limit after filter", 1, []string{"channel-buffering"}},
+		{"limit above count", 10, []string{"channel-buffering", "channels"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := fakeOptions(server)
+			opts.Include = regexp.MustCompile("channel")
+			opts.Limit = tt.limit
+
+			examples, err := GetGitHubFiles(t.TempDir(), opts)
+			if err != nil {
+				t.Fatalf("GetGitHubFiles: %v", err)
+			}
+			var got []string
+			for _, ex := range examples {
+				got = append(got, ex.Title)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RepoConfig locates the gobyexample site the examples are listed from
+//
+// The zero value lists the upstream repository on GitHub.
+type RepoConfig struct {
+	ListingURL string // GitHub page listing the example files; empty uses DefaultListingURL
+	RawBaseURL string // Base URL of the raw files; empty uses DefaultRawBaseURL
+	RepoDir    string // Local gobyexample clone to list instead of GitHub; no network access is needed
+}
+
+// ExampleRef describes an upstream example without its content
+type ExampleRef struct {
+	Name  string // The upstream filename, e.g. "worker-pools"
+	File  string // The sanitized filename used for local files, e.g. "worker_pools"
+	URL   string // The URL of the example's raw HTML
+	Title string // The title from the site's index, e.g. "Worker Pools"; empty if unknown
+}
+
+// indexFile is the site's start page, which links all examples by title
+const indexFile = "index.html"
+
+// indexLinkPattern matches the links to the examples on the site's index,
+// e.g. <a href="worker-pools">Worker Pools</a>
+var indexLinkPattern = regexp.MustCompile(`<a href="([^"/:#?]+)">([^<]+)</a>`)
+
+// ListExamples lists the upstream examples without downloading their content
+//
+// The example names come from the same listing GetGitHubFiles uses. The
+// titles are read from the site's index page, which takes a single extra
+// request; if the index cannot be read, the titles are left empty and the
+// listing is still returned. No assets are downloaded and nothing is written
+// to disk, so this is cheap enough for building a navigation or an index.
+//
+// Parameters:
+//   - repo: Where to list the examples from
+//
+// Returns:
+//   - []ExampleRef: The examples, sorted by name
+//   - error: Any error that occurred while reading the listing; it wraps
+//     ErrListingUnavailable or ErrNoExamplesFound
+//
+// Example:
+//
+//	refs, err := ListExamples(RepoConfig{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, ref := range refs {
+//	    fmt.Printf("%s: %s\n", ref.Name, ref.Title)
+//	}
+func ListExamples(repo RepoConfig) ([]ExampleRef, error) {
+	if repo.ListingURL == "" {
+		repo.ListingURL = DefaultListingURL
+	}
+	if repo.RawBaseURL == "" {
+		repo.RawBaseURL = DefaultRawBaseURL
+	}
+	rawBase := strings.TrimSuffix(repo.RawBaseURL, "/")
+
+	var names []string
+	var err error
+	if repo.RepoDir != "" {
+		names, err = GetExampleFilesFromRepo(repo.RepoDir)
+	} else {
+		names, err = GetExampleFilesFromListing(repo.ListingURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	titles, err := readIndexTitles(repo, rawBase)
+	if err != nil {
+		logger.Warn("Could not read example titles from the index", "err", err)
+	}
+
+	refs := make([]ExampleRef, len(names))
+	for i, name := range names {
+		refs[i] = ExampleRef{
+			Name:  name,
+			File:  sanitizeFilename(name),
+			URL:   rawBase + "/" + name,
+			Title: titles[name],
+		}
+	}
+	return refs, nil
+}
+
+// readIndexTitles returns the example titles from the site's index page
+//
+// Returns:
+//   - map[string]string: The titles by example name
+//   - error: Any error that occurred while reading the index
+func readIndexTitles(repo RepoConfig, rawBase string) (map[string]string, error) {
+	var index string
+	if repo.RepoDir != "" {
+		content, err := os.ReadFile(filepath.Join(publicDir(repo.RepoDir), indexFile))
+		if err != nil {
+			return nil, err
+		}
+		index = string(content)
+	} else {
+		content, err := downloadFile(rawBase+"/"+indexFile, ExampleContentTypes)
+		if err != nil {
+			return nil, err
+		}
+		index = content
+	}
+
+	titles := make(map[string]string)
+	for _, m := range indexLinkPattern.FindAllStringSubmatch(index, -1) {
+		titles[m[1]] = strings.TrimSpace(html.UnescapeString(m[2]))
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("%s links no examples", indexFile)
+	}
+	return titles, nil
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"fmt"
+	"go-by-example-book/internal/logging"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// publicDir returns the directory of a gobyexample clone holding the
+// generated site
+func publicDir(repoDir string) string {
+	return filepath.Join(repoDir, "public")
+}
+
+// GetExampleFilesFromRepo lists the example files of a local gobyexample clone
+//
+// This is the offline counterpart of GetExampleFilesFromListing: it reads the
+// repository's public/ directory instead of GitHub's directory listing and
+// applies the same filters.
+//
+// Parameters:
+//   - repoDir: The root directory of the gobyexample clone
+//
+// Returns:
+//   - []string: A sorted slice of example filenames
+//   - error: Any error that occurred while reading the directory; it wraps
+//     ErrListingUnavailable or ErrNoExamplesFound
+func GetExampleFilesFromRepo(repoDir string) ([]string, error) {
+	dir := publicDir(repoDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrListingUnavailable, err)
+	}
+
+	var exampleFiles []string
+	for _, entry := range entries {
+		if entry.IsDir() || hasAssetExtension(entry.Name()) {
+			continue
+		}
+		if !IsExampleName(entry.Name()) {
+			logger.Info(entry.Name(), logging.Tag("NOT AN EXAMPLE"))
+			continue
+		}
+		exampleFiles = append(exampleFiles, entry.Name())
+	}
+
+	if len(exampleFiles) == 0 {
+		return nil, fmt.Errorf("%w: %s contains no example files", ErrNoExamplesFound, dir)
+	}
+
+	sort.Strings(exampleFiles)
+	logger.Debug("Found example files in local repository", "count", len(exampleFiles))
+	return exampleFiles, nil
+}
+
+// copyRepoFile copies a file of the clone's public/ directory to outputDir
+func copyRepoFile(repoDir, filename, outputDir string) error {
+	content, err := os.ReadFile(filepath.Join(publicDir(repoDir), filename))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, filename), content, 0644)
+}
+
+// readRepoExample reads an example's HTML from the clone's public/ directory
+//
+// Returns:
+//   - string: The HTML content
+//   - time.Time: The modification time of the file
+//   - error: Any error that occurred while reading the file
+func readRepoExample(repoDir, filename string) (string, time.Time, error) {
+	path := filepath.Join(publicDir(repoDir), filename)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read %s from local repository: %v", filename, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read %s from local repository: %v", filename, err)
+	}
+	return string(content), info.ModTime(), nil
+}
+
+// fetchRepoExample resolves an example from the local clone
+//
+// The clone is the source of truth, so existing local HTML files are not
+// matched and no ETags are involved. SourceURL still points to the upstream
+// location the example is published at.
+//
+// Returns:
+//   - Example: The resolved example
+//   - error: Why the example could not be resolved and must be skipped
+func fetchRepoExample(filename string, opts Options) (Example, error) {
+	content, modified, err := readRepoExample(opts.RepoDir, filename)
+	if err != nil {
+		return Example{}, err
+	}
+
+	if err := checkContent(content, opts.MinContentSize); err != nil {
+		return Example{}, fmt.Errorf("implausible content: %v", err)
+	}
+
+	logger.Debug("Read example from local repository", "file", filename)
+	return Example{
+		Title:     filename,
+		Content:   content,
+		File:      sanitizeFilename(filename),
+		SourceURL: strings.TrimSuffix(opts.RawBaseURL, "/") + "/" + filename,
+		Source:    LocalRepo,
+
+		// A checkout sets the time of changed files, so this is never too early
+		LastModified: modified,
+	}, nil
+}
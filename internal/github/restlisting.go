@@ -0,0 +1,95 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// treeURLPattern matches GitHub tree page URLs of the form
+// https://github.com/{owner}/{repo}/tree/{ref}/{path}
+var treeURLPattern = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/tree/([^/]+)/(.+?)/?$`)
+
+// nextLinkPattern extracts the URL of the next page from a Link header
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// contentsAPIURL returns the GitHub REST API URL that lists the same
+// directory as a tree page URL
+//
+// Parameters:
+//   - listingURL: A GitHub tree page URL, e.g. DefaultListingURL
+//
+// Returns:
+//   - string: The contents API URL
+//   - bool: false if listingURL is not a GitHub tree page URL
+//
+// Example:
+//
+//	apiURL, ok := contentsAPIURL(DefaultListingURL)
+//	// Returns: "https://api.github.com/repos/mmcgrana/gobyexample/contents/public?ref=master", true
+func contentsAPIURL(listingURL string) (string, bool) {
+	m := treeURLPattern.FindStringSubmatch(listingURL)
+	if m == nil {
+		return "", false
+	}
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s", m[1], m[2], m[4], m[3]), true
+}
+
+// getExampleFilesFromAPI lists the example files of a directory via the
+// GitHub REST API
+//
+// Unlike the tree page, the API does not truncate large directories; if
+// the response is paginated, the pages are followed via the Link header.
+// The same filtering as for the tree page is applied.
+//
+// Parameters:
+//   - apiURL: The contents API URL of the directory, see contentsAPIURL
+//
+// Returns:
+//   - []string: The example names of all pages
+//   - error: Any error that occurred while requesting or decoding a page
+func getExampleFilesFromAPI(apiURL string) ([]string, error) {
+	var exampleFiles []string
+	for url := apiURL; url != ""; {
+		logger.Debug("Fetching directory listing from the REST API", "url", url)
+		req, err := newRequest(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newHTTPError(url, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var items []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&items)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", url, err)
+		}
+
+		for _, item := range items {
+			if item.Type == "file" && isListedExample(item.Name) {
+				exampleFiles = append(exampleFiles, item.Name)
+			}
+		}
+
+		url = ""
+		if m := nextLinkPattern.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			url = m[1]
+		}
+	}
+	return exampleFiles, nil
+}
@@ -12,11 +12,14 @@
 //
 // Example usage:
 //
-//	browser := rod.New().MustConnect()
-//	defer browser.MustClose()
+//	browser, closeBrowser, err := htmlpdf.NewBrowser()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer closeBrowser()
 //
 //	// Create an HTML file
-//	err := htmlpdf.CreateHTMLFile("<html><body><h1>Hello World</h1></body></html>", "output.html")
+//	err = htmlpdf.CreateHTMLFile("<html><body><h1>Hello World</h1></body></html>", "output.html")
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -42,7 +45,34 @@ package htmlpdf
 // Returns:
 //   - string: The complete HTML template as a string
 func CreateBaseHtmlTemplate() string {
-	return `<!DOCTYPE html>
+	return CreateBaseHtmlTemplateWithPreface("")
+}
+
+// CreateBaseHtmlTemplateWithPreface creates the base HTML template for the
+// introduction page with a user-supplied preface
+//
+// The preface is placed on its own pages between the introduction and the
+// Table of Contents, e.g. for course information or licensing terms. It is
+// an HTML fragment; if a complete document is given, only its <body> is
+// used. The preface can use the classes of site.css and of the intro page
+// styles, e.g. "intro" for a highlighted box.
+//
+// Parameters:
+//   - preface: The preface HTML; empty creates the same template as CreateBaseHtmlTemplate
+//
+// Returns:
+//   - string: The complete HTML template as a string
+func CreateBaseHtmlTemplateWithPreface(preface string) string {
+	template := introHeaderTemplate
+	if preface != "" {
+		template += "\n    <div class=\"preface\" style=\"page-break-before: always;\">\n" + extractBody(preface) + "\n    </div>\n"
+	}
+	return template + tocHeaderTemplate
+}
+
+// introHeaderTemplate is the start of the introduction page, up to the end
+// of the introductory text
+const introHeaderTemplate = `<!DOCTYPE html>
 <html>
 <head>
     <meta charset="UTF-8">
@@ -89,9 +119,19 @@ func CreateBaseHtmlTemplate() string {
             margin-bottom: 6px;
             line-height: 1.3;
         }
+        .toc-section {
+            color: #0066cc;
+            font-size: 15px;
+            margin: 14px 0 4px 0;
+            page-break-after: avoid;
+        }
         .page-number {
             color: #666;
             font-weight: bold;
+            /* Fixed width so the page numbers never change how entries wrap */
+            display: inline-block;
+            min-width: 6.5em;
+            white-space: nowrap;
         }
         .page-number a {
             color: #0066cc;
@@ -116,11 +156,14 @@ func CreateBaseHtmlTemplate() string {
         <p>This e-book was automatically generated from the contents of the <a href="https://github.com/mmcgrana/gobyexample">Go by Example repository</a> using the <a href="https://github.com/wunderkind2k1/go-by-example-book-generator">go-by-example-book-generator</a> tool.</p>
         <p>The original Go by Example site is a comprehensive collection of annotated example programs that teach Go programming concepts through practical examples. This e-book format makes it easy to read offline and navigate through the examples using PDF bookmarks.</p>
     </div>
+`
 
+// tocHeaderTemplate starts the Table of Contents on a new page; the TOC
+// entries follow it
+const tocHeaderTemplate = `
     <div style="page-break-before: always;"></div>
 
     <h2>Table of Contents</h2>
     <div class="toc-container">
         <ul>
 `
-}
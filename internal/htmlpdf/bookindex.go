@@ -0,0 +1,141 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go-by-example-book/internal/github"
+)
+
+// BuildIndexHTML returns the alphabetical index page placed after the
+// examples
+//
+// The TOC lists the examples in book order, which is meant to be read from
+// start to end; the index lists them alphabetically by title, case
+// insensitively, under a heading for every initial letter. Titles that do
+// not start with a letter are listed under "#". Like in the TOC, every page
+// number is a "#page=N" link, see ResolvePageLinks.
+//
+// The page of an example does not depend on the length of the index, so
+// unlike the intro it is rendered once.
+//
+// Parameters:
+//   - examples: The examples in book order
+//   - firstPage: The page number of the first example
+//   - pageCounts: The page count of each example, indexed like examples
+//
+// Returns:
+//   - string: The HTML document of the index
+//
+// Example:
+//
+//	html := BuildIndexHTML(examples, introPageCount+1, pageCounts)
+//	err := CreateHTMLFile(html, "index.html")
+func BuildIndexHTML(examples []github.Example, firstPage int, pageCounts []int) string {
+	type entry struct {
+		title string
+		page  int
+	}
+	entries := make([]entry, len(examples))
+	page := firstPage
+	for i, ex := range examples {
+		entries[i] = entry{title: ex.Title, page: page}
+		if i < len(pageCounts) {
+			page += pageCounts[i]
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return strings.ToLower(entries[a].title) < strings.ToLower(entries[b].title)
+	})
+
+	var b strings.Builder
+	b.WriteString(indexHeaderTemplate)
+	letter := ""
+	for _, e := range entries {
+		if l := indexLetter(e.title); l != letter {
+			if letter != "" {
+				b.WriteString("        </ul>\n")
+			}
+			fmt.Fprintf(&b, "        <h3 class=\"index-letter\">%s</h3>\n        <ul>\n", l)
+			letter = l
+		}
+		fmt.Fprintf(&b, "        <li>%s <span class=\"page-number\"><a href=\"#page=%d\">%d</a></span></li>\n", html.EscapeString(e.title), e.page, e.page)
+	}
+	if letter != "" {
+		b.WriteString("        </ul>\n")
+	}
+	b.WriteString(indexFooterTemplate)
+	return b.String()
+}
+
+// indexLetter returns the heading an index entry is listed under
+func indexLetter(title string) string {
+	for _, r := range title {
+		if unicode.IsLetter(r) {
+			return string(unicode.ToUpper(r))
+		}
+		break
+	}
+	return "#"
+}
+
+// indexHeaderTemplate is the start of the index page, up to its entries
+const indexHeaderTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Go by Example - Index</title>
+    <link rel="stylesheet" href="site.css">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            margin: 30px;
+            line-height: 1.6;
+        }
+        h1 {
+            color: #333;
+            border-bottom: 2px solid #333;
+            padding-bottom: 8px;
+            font-size: 24px;
+            margin-bottom: 20px;
+        }
+        .index-container {
+            font-size: 14px;
+            line-height: 1.4;
+            column-count: 2;
+            column-gap: 30px;
+        }
+        .index-container ul {
+            list-style: none;
+            padding-left: 0;
+            margin: 0 0 10px 0;
+        }
+        .index-container li {
+            margin-bottom: 4px;
+            break-inside: avoid;
+        }
+        .index-letter {
+            color: #0066cc;
+            font-size: 15px;
+            margin: 10px 0 4px 0;
+            break-after: avoid;
+        }
+        .page-number a {
+            color: #666;
+            font-weight: bold;
+            text-decoration: none;
+        }
+    </style>
+</head>
+<body>
+    <h1>Index</h1>
+    <div class="index-container">
+`
+
+// indexFooterTemplate closes the index page
+const indexFooterTemplate = `    </div>
+</body>
+</html>`
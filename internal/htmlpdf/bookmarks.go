@@ -2,10 +2,10 @@ package htmlpdf
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/logging"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
@@ -19,14 +19,38 @@ type ApplyBookmarksParams struct {
 	Examples          []github.Example // Slice of examples to create bookmarks for
 	IntroPageCount    int              // Number of pages in the introduction section
 	ExamplePageCounts []int            // Slice containing page counts for each example
+	Categories        []string         // Optional category for each example; when set, examples are nested under category bookmarks
+	Numbers           []int            // Optional number shown for each example; nil numbers the examples by position
+	IndexPageCount    int              // Pages of the alphabetical index following the examples; 0 for none
+	ShowBookmarks     bool             // Open the viewer's bookmark panel when the PDF is opened
+	KeepTempMergedPDF bool             // Keep TempMergedPDF instead of removing it once the final PDF is written
 }
 
 // ApplyBookmarks adds navigation bookmarks to a PDF file
 //
 // This function creates a structured bookmark hierarchy for the PDF,
 // including an introduction bookmark and individual bookmarks for each
-// example with correct page ranges. The bookmarks provide easy navigation
-// through the PDF document.
+// example with correct page ranges, followed by an index bookmark when
+// IndexPageCount is set. The bookmarks provide easy navigation through the
+// PDF document.
+//
+// When Categories is provided, consecutive examples sharing a category are
+// grouped under a top-level category bookmark, producing a two-level tree.
+// Without categories the bookmarks form a flat list.
+//
+// A page count below 1 leaves no page range to point to, and pdfcpu writes
+// a broken outline for a bookmark ending before it starts. Such bookmarks
+// are skipped with a warning; the following examples keep their pages.
+//
+// The examples are numbered by their position unless Numbers is set. The
+// build sets it when the numbers are printed on the example pages, so the
+// bookmarks keep the printed numbers even if an example was left out.
+//
+// pdfcpu's Bookmark type has no open/closed flag: every node is written with
+// a positive /Count, so the expanded state of individual nodes cannot be
+// controlled and is left to the viewer. ShowBookmarks instead sets the
+// document's PageMode to UseOutlines so the bookmark panel is visible as soon
+// as the PDF is opened.
 //
 // The function handles the case where bookmark creation might fail by
 // falling back to simply renaming the temporary file to the final filename.
@@ -35,7 +59,8 @@ type ApplyBookmarksParams struct {
 //   - params: ApplyBookmarksParams struct containing all necessary parameters
 //
 // Returns:
-//   - error: Any error that occurred during bookmark creation
+//   - error: Any error that occurred during bookmark creation, including a
+//     mismatch between the number of examples and page counts
 //
 // Example:
 //
@@ -44,45 +69,144 @@ type ApplyBookmarksParams struct {
 //	    log.Fatal(err)
 //	}
 func ApplyBookmarks(params ApplyBookmarksParams) error {
-	fmt.Println("[INFO] Adding bookmarks to PDF...")
+	// Every example needs a page count, otherwise the page ranges cannot be computed
+	if len(params.Examples) != len(params.ExamplePageCounts) {
+		return fmt.Errorf("cannot compute bookmark page ranges: %d examples but %d page counts",
+			len(params.Examples), len(params.ExamplePageCounts))
+	}
+
+	logger.Info("Adding bookmarks to PDF...")
 
 	var bookmarks []pdfcpu.Bookmark
 
 	// Add intro bookmark
-	bookmarks = append(bookmarks, pdfcpu.Bookmark{
-		Title:    "Introduction & Table of Contents",
-		PageFrom: 1,
-		PageThru: params.IntroPageCount, // Intro and TOC span the actual number of pages
-	})
+	if params.IntroPageCount > 0 {
+		bookmarks = append(bookmarks, pdfcpu.Bookmark{
+			Title:    "Introduction & Table of Contents",
+			PageFrom: 1,
+			PageThru: params.IntroPageCount, // Intro and TOC span the actual number of pages
+		})
+	} else {
+		logger.Warn("Skipping intro bookmark with an empty page range", "pages", params.IntroPageCount)
+	}
 
 	// Add bookmarks for each example with correct page ranges
 	// Examples start after the intro pages
-	exampleStartPage := params.IntroPageCount + 1
+	var exampleBookmarks []pdfcpu.Bookmark
+	var exampleCategories []string // Categories of the bookmarks kept, in lockstep with exampleBookmarks
+	exampleStartPage := max(params.IntroPageCount, 0) + 1
 	for i, ex := range params.Examples {
 		pageCount := params.ExamplePageCounts[i]
-		bookmarks = append(bookmarks, pdfcpu.Bookmark{
-			Title:    fmt.Sprintf("%d. %s", i+1, ex.Title),
+		number := i + 1
+		if i < len(params.Numbers) {
+			number = params.Numbers[i]
+		}
+
+		// An example without pages has no range to point to; a bookmark
+		// with PageThru < PageFrom would break the outline
+		if pageCount < 1 {
+			logger.Warn("Skipping bookmark with an empty page range", "example", ex.Title, "pages", pageCount)
+			continue
+		}
+
+		exampleBookmarks = append(exampleBookmarks, pdfcpu.Bookmark{
+			Title:    fmt.Sprintf("%d. %s", number, ex.Title),
 			PageFrom: exampleStartPage,
 			PageThru: exampleStartPage + pageCount - 1, // -1 because PageThru is inclusive
 		})
+		category := ""
+		if i < len(params.Categories) {
+			category = params.Categories[i]
+		}
+		exampleCategories = append(exampleCategories, category)
 		exampleStartPage += pageCount // Move to the next example's starting page
 	}
 
+	if len(params.Categories) > 0 {
+		exampleBookmarks = groupBookmarksByCategory(exampleBookmarks, exampleCategories)
+	}
+	bookmarks = append(bookmarks, exampleBookmarks...)
+
+	// The index follows the last example
+	if params.IndexPageCount > 0 {
+		bookmarks = append(bookmarks, pdfcpu.Bookmark{
+			Title:    "Index",
+			PageFrom: exampleStartPage,
+			PageThru: exampleStartPage + params.IndexPageCount - 1,
+		})
+	}
+
 	// Add bookmarks to the final PDF
 	conf := model.NewDefaultConfiguration()
 	err := api.AddBookmarksFile(params.TempMergedPDF, params.FinalPDF, bookmarks, true, conf)
 	if err != nil {
-		log.Printf("[WARNING] Could not add bookmarks: %v", err)
+		logger.Warn("Could not add bookmarks", "err", err)
 		// If bookmark creation fails, just copy the temp file
 		err = os.Rename(params.TempMergedPDF, params.FinalPDF)
 		if err != nil {
 			return fmt.Errorf("could not rename temp file: %v", err)
 		}
 	} else {
-		fmt.Println("[BOOKMARKS ADDED] Navigation bookmarks created")
+		logger.Info("Navigation bookmarks created", logging.Tag("BOOKMARKS ADDED"))
 		// Remove the temp file since we created the final one with bookmarks
-		os.Remove(params.TempMergedPDF)
+		if !params.KeepTempMergedPDF {
+			os.Remove(params.TempMergedPDF)
+		}
+
+		if params.ShowBookmarks {
+			// Open the bookmark panel by default; failure only affects viewer presentation
+			err = api.SetPageModeFile(params.FinalPDF, "", model.PageModeUseOutlines, conf)
+			if err != nil {
+				logger.Warn("Could not set page mode", "err", err)
+			}
+		}
 	}
 
 	return nil
 }
+
+// groupBookmarksByCategory nests example bookmarks under category bookmarks
+//
+// Consecutive bookmarks that share the same category are collected as the
+// Kids of a single category bookmark spanning their combined page range.
+// Bookmarks without a category (empty string or missing entry) stay at the
+// top level so they are never dropped from the outline.
+//
+// Parameters:
+//   - bookmarks: The flat list of example bookmarks in document order
+//   - categories: The category for each bookmark, indexed like bookmarks
+//
+// Returns:
+//   - []pdfcpu.Bookmark: The two-level bookmark tree
+func groupBookmarksByCategory(bookmarks []pdfcpu.Bookmark, categories []string) []pdfcpu.Bookmark {
+	var grouped []pdfcpu.Bookmark
+
+	for i, bm := range bookmarks {
+		category := ""
+		if i < len(categories) {
+			category = categories[i]
+		}
+
+		if category == "" {
+			grouped = append(grouped, bm)
+			continue
+		}
+
+		// Extend the previous category bookmark if this example continues it
+		if i > 0 && i-1 < len(categories) && categories[i-1] == category {
+			parent := &grouped[len(grouped)-1]
+			parent.Kids = append(parent.Kids, bm)
+			parent.PageThru = bm.PageThru
+			continue
+		}
+
+		grouped = append(grouped, pdfcpu.Bookmark{
+			Title:    category,
+			PageFrom: bm.PageFrom,
+			PageThru: bm.PageThru,
+			Kids:     []pdfcpu.Bookmark{bm},
+		})
+	}
+
+	return grouped
+}
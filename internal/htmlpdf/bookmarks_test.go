@@ -0,0 +1,121 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go-by-example-book/internal/github"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// outline describes the bookmarks of a PDF as "title@page", indenting kids
+func outline(t *testing.T, pdfPath string) []string {
+	t.Helper()
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bookmarks, err := api.Bookmarks(f, nil)
+	if err != nil {
+		t.Fatalf("reading bookmarks: %v", err)
+	}
+
+	var lines []string
+	var walk func(bms []pdfcpu.Bookmark, indent string)
+	walk = func(bms []pdfcpu.Bookmark, indent string) {
+		for _, bm := range bms {
+			lines = append(lines, fmt.Sprintf("%s%s@%d", indent, bm.Title, bm.PageFrom))
+			walk(bm.Kids, indent+"  ")
+		}
+	}
+	walk(bookmarks, "")
+	return lines
+}
+
+func TestApplyBookmarksSkipsEmptyPageRanges(t *testing.T) {
+	examples := []github.Example{{Title: "values"}, {Title: "broken"}, {Title: "closures"}, {Title: "lost"}, {Title: "enums"}}
+
+	tests := []struct {
+		name       string
+		categories []string
+		want       []string
+	}{
+		{"flat", nil, []string{
+			"Introduction & Table of Contents@1",
+			"1. values@3",
+			"3. closures@5",
+			"5. enums@8",
+			"Index@9",
+		}},
+		{"categories", []string{"Basics", "Basics", "Basics", "Types", "Types"}, []string{
+			"Introduction & Table of Contents@1",
+			"Basics@3",
+			"  1. values@3",
+			"  3. closures@5",
+			"Types@8",
+			"  5. enums@8",
+			"Index@9",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := writeTestPDF(t, 9)
+			final := filepath.Join(t.TempDir(), "book.pdf")
+			err := ApplyBookmarks(ApplyBookmarksParams{
+				TempMergedPDF:     merged,
+				FinalPDF:          final,
+				Examples:          examples,
+				IntroPageCount:    2,
+				ExamplePageCounts: []int{2, 0, 3, -1, 1},
+				Categories:        tt.categories,
+				IndexPageCount:    1,
+			})
+			if err != nil {
+				t.Fatalf("ApplyBookmarks: %v", err)
+			}
+
+			// The empty examples are skipped; the following ones keep their pages
+			if got := outline(t, final); !slices.Equal(got, tt.want) {
+				t.Errorf("got bookmarks\n%q\nwant\n%q", got, tt.want)
+			}
+			if _, err := os.Stat(merged); !os.IsNotExist(err) {
+				t.Errorf("temporary merged PDF was not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyBookmarksWithoutIntro(t *testing.T) {
+	merged := writeTestPDF(t, 3)
+	final := filepath.Join(t.TempDir(), "book.pdf")
+	err := ApplyBookmarks(ApplyBookmarksParams{
+		TempMergedPDF:     merged,
+		FinalPDF:          final,
+		Examples:          []github.Example{{Title: "values"}, {Title: "closures"}},
+		ExamplePageCounts: []int{1, 2},
+		Numbers:           []int{4, 7},
+	})
+	if err != nil {
+		t.Fatalf("ApplyBookmarks: %v", err)
+	}
+	want := []string{"4. values@1", "7. closures@2"}
+	if got := outline(t, final); !slices.Equal(got, want) {
+		t.Errorf("got bookmarks %q, want %q", got, want)
+	}
+}
+
+func TestApplyBookmarksCountMismatch(t *testing.T) {
+	err := ApplyBookmarks(ApplyBookmarksParams{
+		Examples:          []github.Example{{Title: "values"}, {Title: "closures"}},
+		ExamplePageCounts: []int{1},
+	})
+	if err == nil {
+		t.Error("ApplyBookmarks accepted 2 examples with 1 page count")
+	}
+}
@@ -0,0 +1,117 @@
+package htmlpdf
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// browserHint is appended to browser startup errors to tell users how to fix them
+const browserHint = "install Chromium (or Google Chrome) or set ROD_BROWSER_PATH to an existing browser executable; when running as root, e.g. in Docker, use -no-sandbox"
+
+// BrowserOptions controls how NewBrowserWithOptions starts the browser
+//
+// The zero value lets Rod find an installed browser or download one.
+type BrowserOptions struct {
+	// BinPath is the Chromium/Chrome executable to launch; empty lets Rod
+	// find or download a browser. Required where the download is blocked.
+	BinPath string
+
+	// NoSandbox starts Chromium with --no-sandbox. Chromium refuses to run
+	// sandboxed as root, which is the default user of Docker containers and
+	// many CI runners. Only use it for trusted content, since the sandbox
+	// protects the host from the rendered pages.
+	NoSandbox bool
+}
+
+// NewBrowser starts a headless browser for PDF conversion
+//
+// Unlike rod.New().MustConnect(), failures are returned instead of causing
+// a panic: launching fails when no browser is installed or it cannot start,
+// which is common in minimal CI containers, and the error then tells how to
+// fix it.
+//
+// The returned cleanup function closes the browser and removes the files of
+// a browser it launched. It must be called once the conversions are done and
+// is safe to defer right away.
+//
+// Returns:
+//   - *rod.Browser: A connected browser ready for PDF conversion
+//   - func(): Closes the browser and cleans up after it
+//   - error: Any error that occurred while launching or connecting
+//
+// Example:
+//
+//	browser, closeBrowser, err := NewBrowser()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer closeBrowser()
+//
+//	err = HTMLToPDF(browser, "input.html", "output.pdf")
+func NewBrowser() (*rod.Browser, func(), error) {
+	return NewBrowserWithOptions(BrowserOptions{})
+}
+
+// NewBrowserWithOptions starts a headless browser like NewBrowser, applying
+// the given options
+//
+// Parameters:
+//   - opts: Browser options; the zero value starts the browser like NewBrowser
+//
+// Returns:
+//   - *rod.Browser: A connected browser ready for PDF conversion
+//   - func(): Closes the browser and cleans up after it
+//   - error: Any error that occurred while launching or connecting
+func NewBrowserWithOptions(opts BrowserOptions) (*rod.Browser, func(), error) {
+	browser := rod.New()
+
+	// Without options, Connect launches a browser with Rod's defaults
+	l := newLauncher(opts)
+	if l != nil {
+		name := "browser"
+		if opts.BinPath != "" {
+			name += " " + opts.BinPath
+		}
+		controlURL, err := l.Launch()
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not launch %s: %v (%s)", name, err, browserHint)
+		}
+		browser = browser.ControlURL(controlURL)
+	}
+
+	if err := browser.Connect(); err != nil {
+		if l != nil {
+			l.Kill()
+			l.Cleanup()
+		}
+		return nil, nil, fmt.Errorf("could not start headless browser: %v (%s)", err, browserHint)
+	}
+
+	cleanup := func() {
+		err := browser.Close()
+		if l == nil {
+			return
+		}
+		// Cleanup waits for the process to exit, which closing normally does
+		if err != nil {
+			l.Kill()
+		}
+		l.Cleanup()
+	}
+	return browser, cleanup, nil
+}
+
+// newLauncher returns the launcher for the browser options, or nil if they
+// need none and Rod's defaults apply
+func newLauncher(opts BrowserOptions) *launcher.Launcher {
+	if opts.BinPath == "" && !opts.NoSandbox {
+		return nil
+	}
+	l := launcher.New().NoSandbox(opts.NoSandbox)
+	if opts.BinPath != "" {
+		l = l.Bin(opts.BinPath)
+	}
+	return l
+}
@@ -0,0 +1,62 @@
+package htmlpdf
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/launcher/flags"
+)
+
+func TestNewLauncher(t *testing.T) {
+	if l := newLauncher(BrowserOptions{}); l != nil {
+		t.Error("the zero options got a launcher instead of Rod's defaults")
+	}
+
+	tests := []struct {
+		name      string
+		opts      BrowserOptions
+		wantBin   string
+		noSandbox bool
+	}{
+		{"binary", BrowserOptions{BinPath: "/opt/chromium/chrome"}, "/opt/chromium/chrome", false},
+		{"no sandbox", BrowserOptions{NoSandbox: true}, "", true},
+		{"both", BrowserOptions{BinPath: "/usr/bin/chromium", NoSandbox: true}, "/usr/bin/chromium", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLauncher(tt.opts)
+			if l == nil {
+				t.Fatal("no launcher")
+			}
+			if got := l.Get(flags.Bin); got != tt.wantBin {
+				t.Errorf("binary %q, want %q", got, tt.wantBin)
+			}
+			if got := l.Has(flags.NoSandbox); got != tt.noSandbox {
+				t.Errorf("no-sandbox flag %v, want %v", got, tt.noSandbox)
+			}
+			// The browser is always started headless
+			if !l.Has(flags.Headless) {
+				t.Error("the browser is not started headless")
+			}
+		})
+	}
+}
+
+func TestNewBrowserWithOptionsMissingBinary(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "no-such-chromium")
+	browser, cleanup, err := NewBrowserWithOptions(BrowserOptions{BinPath: bin})
+	if err == nil {
+		cleanup()
+		t.Fatal("a browser was started from a missing binary")
+	}
+	if browser != nil || cleanup != nil {
+		t.Error("a browser or cleanup was returned with the error")
+	}
+	// The error names the binary and tells how to fix it
+	for _, want := range []string{bin, browserHint} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
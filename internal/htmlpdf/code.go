@@ -0,0 +1,93 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ExtractCode returns the Go source of an example page without the prose
+//
+// gobyexample splits every example into segments, each a table row with the
+// explanation in a "docs" cell and the code in a "code" cell. The code is
+// highlighted with one <span> per token, so the text of each <pre> is the
+// raw source of the segment. The segments are joined with a blank line,
+// which is where the upstream generator split them. Shell sessions showing
+// how to run the example (blocks starting with "$") are left out so the
+// result reads like the original .go file.
+//
+// Parameters:
+//   - content: The HTML content of an example
+//
+// Returns:
+//   - string: The Go source, ending with a newline; empty if the page has no code
+//   - error: Any error that occurred while parsing the HTML
+//
+// Example:
+//
+//	src, err := ExtractCode(`<td class="code"><pre><span>package</span> main
+//	</pre></td>`)
+//	// Returns: "package main\n"
+func ExtractCode(content string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	var segments []string
+	var walk func(n *html.Node, inCode bool)
+	walk = func(n *html.Node, inCode bool) {
+		if n.Type == html.ElementNode {
+			if n.Data == "td" && hasClass(n, "code") {
+				inCode = true
+			}
+			if inCode && n.Data == "pre" {
+				segment := strings.Trim(nodeText(n), "\n")
+				if segment != "" && !strings.HasPrefix(strings.TrimSpace(segment), "$") {
+					segments = append(segments, segment)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inCode)
+		}
+	}
+	walk(doc, false)
+
+	if len(segments) == 0 {
+		return "", nil
+	}
+	return strings.Join(segments, "\n\n") + "\n", nil
+}
+
+// nodeText returns the concatenated text of a node and its descendants
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return b.String()
+}
+
+// hasClass reports whether an element lists the given class
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"go-by-example-book/internal/github"
+)
+
+// bodyPattern extracts the content of an HTML document's <body>
+var bodyPattern = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+
+// CombinedHTMLParams contains the parameters for WriteCombinedHTML
+type CombinedHTMLParams struct {
+	Examples   []github.Example // Examples in book order, with preprocessed content
+	AssetDir   string           // Directory containing site.css and images
+	OutputPath string           // The path where the combined HTML file should be created
+	ThemeCSS   string           // Optional CSS applied after site.css; empty keeps the site styling
+	PageBreaks bool             // Start every example on a new page when the document is printed
+}
+
+// WriteCombinedHTML writes all examples into one scrollable HTML document
+//
+// This function is a browser-free alternative to the PDF book. The document
+// starts with a table of contents whose entries jump to the examples, followed
+// by the body of every example separated by <hr> elements. site.css and the
+// referenced images are inlined once, so the file can be opened and searched
+// anywhere.
+//
+// Unlike the PDF book, where every example is a document of its own, the
+// examples run together when the file is printed. With params.PageBreaks set,
+// every example section gets a "page-break-before: always" style, so each
+// starts on a new page like in the book.
+//
+// Parameters:
+//   - params: CombinedHTMLParams struct containing all necessary parameters
+//
+// Returns:
+//   - error: Any error that occurred while building or writing the document
+//
+// Example:
+//
+//	err := WriteCombinedHTML(CombinedHTMLParams{
+//	    Examples:   examples,
+//	    AssetDir:   "files",
+//	    OutputPath: "go-by-example.html",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func WriteCombinedHTML(params CombinedHTMLParams) error {
+	var b strings.Builder
+
+	b.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Go by Example</title>
+    <link rel="stylesheet" href="site.css">
+`)
+	if params.ThemeCSS != "" {
+		b.WriteString("    <style>\n" + params.ThemeCSS + "\n    </style>\n")
+	}
+	b.WriteString(`</head>
+<body>
+    <div class="toc">
+        <h1>Go by Example</h1>
+        <h2>Table of Contents</h2>
+        <ul>
+`)
+	for i, ex := range params.Examples {
+		fmt.Fprintf(&b, "        <li><a href=\"#%s\">%s</a></li>\n", combinedAnchor(i), html.EscapeString(ex.Title))
+	}
+	b.WriteString("        </ul>\n    </div>\n")
+
+	sectionStyle := ""
+	if params.PageBreaks {
+		sectionStyle = ` style="page-break-before: always;"`
+	}
+	for i, ex := range params.Examples {
+		fmt.Fprintf(&b, "    <hr>\n    <section id=\"%s\"%s>\n%s\n    </section>\n", combinedAnchor(i), sectionStyle, extractBody(ex.Content))
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	content, err := InlineAssets(b.String(), params.AssetDir)
+	if err != nil {
+		return fmt.Errorf("failed to inline assets: %v", err)
+	}
+
+	if err := CreateHTMLFile(content, params.OutputPath); err != nil {
+		return fmt.Errorf("failed to write combined HTML: %v", err)
+	}
+	return nil
+}
+
+// combinedAnchor returns the fragment identifier of the i-th example
+func combinedAnchor(i int) string {
+	return fmt.Sprintf("example-%d", i+1)
+}
+
+// extractBody returns the content of the document's <body>, or the whole
+// content if it has no body element
+func extractBody(content string) string {
+	if m := bodyPattern.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return content
+}
@@ -0,0 +1,73 @@
+package htmlpdf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-by-example-book/internal/github"
+)
+
+func TestWriteCombinedHTMLPageBreaks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "site.css"), []byte("body { color: #252519; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	examples := []github.Example{
+		{Title: "Values", Content: "<html><body><h2>Values</h2><p>Strings & integers</p></body></html>"},
+		{Title: "Errors <custom>", Content: "<html><body class=\"example\"><h2>Errors</h2></body></html>"},
+		{Title: "Fragment", Content: "<p>No body element</p>"},
+	}
+	const pageBreak = `style="page-break-before: always;"`
+
+	for _, pageBreaks := range []bool{true, false} {
+		outputPath := filepath.Join(dir, "combined.html")
+		err := WriteCombinedHTML(CombinedHTMLParams{
+			Examples:   examples,
+			AssetDir:   dir,
+			OutputPath: outputPath,
+			PageBreaks: pageBreaks,
+		})
+		if err != nil {
+			t.Fatalf("WriteCombinedHTML: %v", err)
+		}
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := string(data)
+
+		// Every section breaks the page, or none does; the TOC never does
+		want := 0
+		if pageBreaks {
+			want = len(examples)
+		}
+		if got := strings.Count(doc, pageBreak); got != want {
+			t.Errorf("PageBreaks %v: %d page breaks, want %d", pageBreaks, got, want)
+		}
+		for _, section := range []string{`<section id="example-1"`, `<section id="example-2"`, `<section id="example-3"`} {
+			if !strings.Contains(doc, section) {
+				t.Errorf("PageBreaks %v: missing %s", pageBreaks, section)
+			}
+		}
+		if pageBreaks && !strings.Contains(doc, `<section id="example-1" `+pageBreak+`>`) {
+			t.Error("the page break is not on the example's section")
+		}
+
+		// The TOC links the sections; the bodies and the CSS are inlined
+		for _, part := range []string{
+			`<a href="#example-2">Errors &lt;custom&gt;</a>`,
+			"<h2>Values</h2><p>Strings & integers</p>",
+			"<p>No body element</p>",
+			"color: #252519",
+		} {
+			if !strings.Contains(doc, part) {
+				t.Errorf("PageBreaks %v: missing %q", pageBreaks, part)
+			}
+		}
+		if strings.Contains(doc, `<body class="example">`) {
+			t.Error("an example's own body tag was copied")
+		}
+	}
+}
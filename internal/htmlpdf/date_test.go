@@ -0,0 +1,88 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestStampDate(t *testing.T) {
+	date := time.Date(2024, 1, 31, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	first, second := writeTestPDF(t, 2), writeTestPDF(t, 2)
+
+	var stamped [2][]byte
+	for i, pdfPath := range []string{first, second} {
+		before, err := os.ReadFile(pdfPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := StampDate(pdfPath, date); err != nil {
+			t.Fatalf("StampDate: %v", err)
+		}
+		if stamped[i], err = os.ReadFile(pdfPath); err != nil {
+			t.Fatal(err)
+		}
+		if len(stamped[i]) != len(before) {
+			t.Errorf("stamping changed the size from %d to %d bytes", len(before), len(stamped[i]))
+		}
+		if err := api.ValidateFile(pdfPath, nil); err != nil {
+			t.Errorf("the stamped PDF is invalid: %v", err)
+		}
+		if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(pdfPath), ".*.tmp")); len(leftovers) > 0 {
+			t.Errorf("temporary files left behind: %v", leftovers)
+		}
+	}
+
+	for _, data := range stamped {
+		dates := datePropertyPattern.FindAll(data, -1)
+		if len(dates) != 2 {
+			t.Fatalf("found %d dates, want 2", len(dates))
+		}
+		for _, d := range dates {
+			if !bytes.Contains(d, []byte("D:20240131113000+00'00'")) {
+				t.Errorf("date %s, want 2024-01-31 11:30 UTC", d)
+			}
+		}
+	}
+	ids := [2][][]byte{fileIDPattern.FindSubmatch(stamped[0]), fileIDPattern.FindSubmatch(stamped[1])}
+	if ids[0] == nil || ids[1] == nil {
+		t.Fatal("the stamped PDFs have no file identifier")
+	}
+	if !bytes.Equal(ids[0][2], ids[0][4]) {
+		t.Errorf("the two parts of the identifier differ: %s and %s", ids[0][2], ids[0][4])
+	}
+	if !bytes.Equal(ids[0][2], ids[1][2]) {
+		t.Errorf("PDFs with the same content have the identifiers %s and %s", ids[0][2], ids[1][2])
+	}
+}
+
+func TestStampDateYearOutOfRange(t *testing.T) {
+	pdfPath := writeTestPDF(t, 1)
+	before, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, date := range []time.Time{
+		time.Date(999, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(9999, 12, 31, 23, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+	} {
+		if err := StampDate(pdfPath, date); err == nil {
+			t.Errorf("StampDate(%v) succeeded, want an error", date)
+		}
+	}
+	if after, _ := os.ReadFile(pdfPath); !bytes.Equal(after, before) {
+		t.Error("a rejected date changed the file")
+	}
+}
+
+func TestStampDateMissingFile(t *testing.T) {
+	if err := StampDate(filepath.Join(t.TempDir(), "missing.pdf"), time.Now()); err == nil {
+		t.Error("StampDate succeeded for a missing file")
+	}
+}
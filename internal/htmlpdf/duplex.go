@@ -0,0 +1,44 @@
+package htmlpdf
+
+import (
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// PadToEvenPages writes a copy of a PDF with a blank page appended if its
+// page count is odd
+//
+// In a double-sided print every document of even length ends on a left-hand
+// page, so the next one merged after it starts on a right-hand page. The
+// blank page has the size of the document's last page.
+//
+// Parameters:
+//   - inPath: The PDF file to pad
+//   - outPath: The path of the padded copy; only written if padding is needed
+//   - pageCount: The page count of inPath
+//
+// Returns:
+//   - string: The path to use, outPath if a page was added and inPath otherwise
+//   - int: The page count of the returned file
+//   - error: Any error that occurred while adding the blank page
+//
+// Example:
+//
+//	path, pages, err := PadToEvenPages("files/hello_world.pdf", "/tmp/hello_world.pdf", 1)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// path == "/tmp/hello_world.pdf", pages == 2
+func PadToEvenPages(inPath, outPath string, pageCount int) (string, int, error) {
+	if pageCount%2 == 0 {
+		return inPath, pageCount, nil
+	}
+
+	conf := model.NewDefaultConfiguration()
+	if err := api.InsertPagesFile(inPath, outPath, []string{"l"}, false, conf); err != nil {
+		return "", 0, fmt.Errorf("could not add blank page to %s: %v", inPath, err)
+	}
+	return outPath, pageCount + 1, nil
+}
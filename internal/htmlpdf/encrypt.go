@@ -0,0 +1,76 @@
+package htmlpdf
+
+import (
+	"fmt"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Encryption describes the password protection of a PDF
+type Encryption struct {
+	UserPassword  string // Password required to open the PDF; empty lets anyone open it
+	OwnerPassword string // Password required to change permissions; empty uses UserPassword
+	AllowPrint    bool   // Allow printing without the owner password
+	AllowCopy     bool   // Allow copying text and graphics without the owner password
+}
+
+// DefaultEncryption returns the permissions used when only passwords are
+// configured: printing is allowed, copying is not
+func DefaultEncryption() Encryption {
+	return Encryption{AllowPrint: true}
+}
+
+// Enabled reports whether a password is set, i.e. whether EncryptPDF does anything
+func (e Encryption) Enabled() bool {
+	return e.UserPassword != "" || e.OwnerPassword != ""
+}
+
+// EncryptPDF password-protects a PDF in place using AES-256
+//
+// This must be the last step applied to a PDF, since all other processing
+// of the encrypted file would require the password. When no password is set
+// the function does nothing.
+//
+// Parameters:
+//   - pdfPath: The PDF file to encrypt; it is overwritten
+//   - enc: The passwords and permissions to apply
+//
+// Returns:
+//   - error: Any error that occurred while encrypting
+//
+// Example:
+//
+//	enc := DefaultEncryption()
+//	enc.UserPassword = "students"
+//	enc.OwnerPassword = "teacher"
+//	if err := EncryptPDF("book.pdf", enc); err != nil {
+//	    log.Fatal(err)
+//	}
+func EncryptPDF(pdfPath string, enc Encryption) error {
+	if !enc.Enabled() {
+		return nil
+	}
+
+	conf := model.NewAESConfiguration(enc.UserPassword, enc.OwnerPassword, 256)
+	if enc.OwnerPassword == "" {
+		conf.OwnerPW = enc.UserPassword
+	}
+
+	conf.Permissions = model.PermissionsNone
+	if enc.AllowPrint {
+		conf.Permissions |= model.PermissionPrintRev2 | model.PermissionPrintRev3
+	}
+	if enc.AllowCopy {
+		conf.Permissions |= model.PermissionExtract | model.PermissionExtractRev3
+	}
+
+	if err := api.EncryptFile(pdfPath, "", conf); err != nil {
+		return fmt.Errorf("could not encrypt %s: %v", pdfPath, err)
+	}
+
+	logger.Info(pdfPath, logging.Tag("ENCRYPTED"))
+	return nil
+}
@@ -0,0 +1,92 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// withPasswords returns a pdfcpu configuration for opening an encrypted PDF
+func withPasswords(user, owner string) *model.Configuration {
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = user
+	conf.OwnerPW = owner
+	return conf
+}
+
+func TestEncryptPDF(t *testing.T) {
+	pdfPath := writeTestPDF(t, 2)
+	enc := DefaultEncryption()
+	enc.UserPassword = "students"
+	enc.OwnerPassword = "teacher"
+	if err := EncryptPDF(pdfPath, enc); err != nil {
+		t.Fatalf("EncryptPDF: %v", err)
+	}
+
+	// Without the password or with a wrong one the PDF cannot be opened
+	if err := api.ValidateFile(pdfPath, nil); err == nil {
+		t.Error("the encrypted PDF opened without a password")
+	}
+	if err := api.ValidateFile(pdfPath, withPasswords("guess", "")); err == nil {
+		t.Error("the encrypted PDF opened with a wrong password")
+	}
+
+	// The user password opens it
+	if err := api.ValidateFile(pdfPath, withPasswords("students", "")); err != nil {
+		t.Errorf("the user password does not open the PDF: %v", err)
+	}
+
+	// Printing is allowed and copying is not
+	perms, err := api.GetPermissionsFile(pdfPath, withPasswords("students", "teacher"))
+	if err != nil {
+		t.Fatalf("reading permissions: %v", err)
+	}
+	if perms == nil {
+		t.Fatal("the PDF has no permissions")
+	}
+	p := model.PermissionFlags(*perms)
+	if p&model.PermissionPrintRev2 == 0 {
+		t.Error("printing is not allowed")
+	}
+	if p&model.PermissionExtract != 0 {
+		t.Error("copying is allowed")
+	}
+}
+
+func TestEncryptPDFUserPasswordOnly(t *testing.T) {
+	pdfPath := writeTestPDF(t, 1)
+	enc := DefaultEncryption()
+	enc.UserPassword = "students"
+	if err := EncryptPDF(pdfPath, enc); err != nil {
+		t.Fatalf("EncryptPDF: %v", err)
+	}
+
+	if err := api.ValidateFile(pdfPath, nil); err == nil {
+		t.Error("the encrypted PDF opened without a password")
+	}
+	// The user password doubles as the owner password
+	if err := api.DecryptFile(pdfPath, "", withPasswords("students", "students")); err != nil {
+		t.Errorf("the user password is not the owner password: %v", err)
+	}
+}
+
+func TestEncryptPDFDisabled(t *testing.T) {
+	pdfPath := writeTestPDF(t, 1)
+	before, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptPDF(pdfPath, DefaultEncryption()); err != nil {
+		t.Fatalf("EncryptPDF: %v", err)
+	}
+	after, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("the PDF was changed without a password")
+	}
+}
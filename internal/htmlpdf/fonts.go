@@ -0,0 +1,149 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fontFormats maps font file extensions to their CSS format() hint
+var fontFormats = map[string]string{
+	".ttf":   "truetype",
+	".otf":   "opentype",
+	".woff":  "woff",
+	".woff2": "woff2",
+}
+
+var (
+	// cssCommentPattern matches a CSS comment
+	cssCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+	// cssRulePattern matches a rule without nested blocks; the rules inside
+	// an @media block are matched on their own
+	cssRulePattern = regexp.MustCompile(`([^{}]+)\{([^{}]*)\}`)
+
+	// fontFamilyPattern matches a font-family declaration of a rule
+	fontFamilyPattern = regexp.MustCompile(`(?i)(?:^|;)\s*font-family\s*:\s*([^;]+)`)
+)
+
+// fontStack is the font-family declaration of a stylesheet rule
+type fontStack struct {
+	selector  string // The selector of the rule, e.g. "pre, code"
+	families  string // The declared families, e.g. "'Menlo', monospace"
+	important bool   // Whether the declaration is !important
+}
+
+// defaultFontStacks are the browser's fonts, used when no stylesheet
+// declares any
+var defaultFontStacks = []fontStack{
+	{selector: "body", families: "serif"},
+	{selector: "pre, code", families: "monospace"},
+}
+
+// fontStacks returns the font-family declarations of the stylesheets, in
+// the order they apply
+//
+// Declarations inside @font-face and other at-rules are not font stacks and
+// are left out, as are the font shorthands.
+func fontStacks(stylesheets []string) []fontStack {
+	var stacks []fontStack
+	for _, css := range stylesheets {
+		css = cssCommentPattern.ReplaceAllString(css, "")
+		for _, rule := range cssRulePattern.FindAllStringSubmatch(css, -1) {
+			// Statements like @import end up in front of the selector
+			selector := rule[1]
+			if i := strings.LastIndex(selector, ";"); i >= 0 {
+				selector = selector[i+1:]
+			}
+			selector = strings.Join(strings.Fields(selector), " ")
+			if selector == "" || strings.HasPrefix(selector, "@") {
+				continue
+			}
+
+			for _, decl := range fontFamilyPattern.FindAllStringSubmatch(rule[2], -1) {
+				stack := fontStack{selector: selector, families: strings.TrimSpace(decl[1])}
+				if i := strings.Index(strings.ToLower(stack.families), "!important"); i >= 0 {
+					stack.families = strings.TrimSpace(stack.families[:i])
+					stack.important = true
+				}
+				stacks = append(stacks, stack)
+			}
+		}
+	}
+	return stacks
+}
+
+// FontFaceCSS builds a stylesheet that makes custom font files available
+//
+// Chromium goes through the families of a font-family list for every glyph,
+// generic families like serif included, and takes the first font that has
+// it. The fonts are therefore added at the end of every font stack of the
+// given stylesheets, with the stack's own selector: the site's typography
+// keeps its look, and characters its fonts cannot display (e.g. CJK) are
+// taken from the custom fonts instead of showing up as empty boxes. If the
+// stylesheets declare no font stack, the browser's default serif and
+// monospace stacks are extended. Chromium embeds every font used on a page
+// into the PDF, so readers do not need the fonts installed.
+//
+// The fonts are referenced by absolute file:// URLs, so the stylesheet only
+// works on the machine that generated it.
+//
+// Parameters:
+//   - fontFiles: Paths of TTF, OTF, WOFF or WOFF2 font files, in order of preference
+//   - stylesheets: The stylesheets of the pages in the order they apply,
+//     e.g. site.css and a theme
+//
+// Returns:
+//   - string: The CSS to apply after the stylesheets; empty if no fonts are given
+//   - error: Any error regarding a missing or unsupported font file
+//
+// Example:
+//
+//	css, err := FontFaceCSS([]string{"/usr/share/fonts/NotoSansJP-Regular.ttf"}, siteCSS)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	opts := PDFOptions{ThemeCSS: css}
+func FontFaceCSS(fontFiles []string, stylesheets ...string) (string, error) {
+	if len(fontFiles) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	var families []string
+	for i, path := range fontFiles {
+		format, ok := fontFormats[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			return "", fmt.Errorf("unsupported font file %s: expected .ttf, .otf, .woff or .woff2", path)
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to get absolute path of %s: %v", path, err)
+		}
+		if _, err := os.Stat(absPath); err != nil {
+			return "", fmt.Errorf("font file not found: %v", err)
+		}
+
+		family := fmt.Sprintf("custom-font-%d", i+1)
+		families = append(families, `"`+family+`"`)
+		fmt.Fprintf(&b, "@font-face {\n    font-family: \"%s\";\n    src: url(\"file://%s\") format(\"%s\");\n}\n", family, filepath.ToSlash(absPath), format)
+	}
+
+	stacks := fontStacks(stylesheets)
+	if len(stacks) == 0 {
+		stacks = defaultFontStacks
+	}
+	custom := strings.Join(families, ", ")
+	for _, stack := range stacks {
+		important := ""
+		if stack.important {
+			important = " !important"
+		}
+		fmt.Fprintf(&b, "%s {\n    font-family: %s, %s%s;\n}\n", stack.selector, stack.families, custom, important)
+	}
+
+	return b.String(), nil
+}
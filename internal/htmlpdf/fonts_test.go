@@ -0,0 +1,251 @@
+package htmlpdf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/font/sfnt"
+)
+
+// siteFontsCSS declares font stacks like the site's site.css
+const siteFontsCSS = `@charset "utf-8";
+/* Fonts of the site */
+body {
+    font-family: 'Georgia', serif;
+    color: #252519;
+}
+pre, code {
+    font-size: 14px;
+    font-family: 'Menlo', 'Monaco', monospace;
+}
+@font-face {
+    font-family: "Site Icons";
+    src: url("icons.woff2");
+}
+@media print {
+    h2 { font-family: Helvetica, sans-serif !important; }
+}
+`
+
+// writeFontFiles creates empty font files in a temporary directory
+func writeFontFiles(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("font data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestFontFaceCSS(t *testing.T) {
+	fonts := writeFontFiles(t, "NotoSansJP-Regular.ttf", "NotoSansSC-Regular.OTF")
+	theme := `td.code pre { font-family: "Fira Code"; }`
+
+	css, err := FontFaceCSS(fonts, siteFontsCSS, theme)
+	if err != nil {
+		t.Fatalf("FontFaceCSS: %v", err)
+	}
+
+	// Every font is declared with its absolute file URL and format
+	for _, want := range []string{
+		`font-family: "custom-font-1";` + "\n    src: url(\"file://" + filepath.ToSlash(fonts[0]) + `") format("truetype");`,
+		`font-family: "custom-font-2";` + "\n    src: url(\"file://" + filepath.ToSlash(fonts[1]) + `") format("opentype");`,
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("missing font face %q in\n%s", want, css)
+		}
+	}
+
+	// Every font stack of the stylesheets is declared again with the fonts
+	// at its end, in the given order; nothing else is set
+	for _, want := range []string{
+		"body {\n    font-family: 'Georgia', serif, \"custom-font-1\", \"custom-font-2\";\n}",
+		"pre, code {\n    font-family: 'Menlo', 'Monaco', monospace, \"custom-font-1\", \"custom-font-2\";\n}",
+		"h2 {\n    font-family: Helvetica, sans-serif, \"custom-font-1\", \"custom-font-2\" !important;\n}",
+		"td.code pre {\n    font-family: \"Fira Code\", \"custom-font-1\", \"custom-font-2\";\n}",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("missing extended stack %q in\n%s", want, css)
+		}
+	}
+	if got := strings.Count(css, "font-family:"); got != 6 {
+		t.Errorf("got %d font-family declarations, want 2 font faces and 4 stacks:\n%s", got, css)
+	}
+	for _, unwanted := range []string{"Site Icons", "color", "font-size", "Times New Roman"} {
+		if strings.Contains(css, unwanted) {
+			t.Errorf("the stylesheet contains %q:\n%s", unwanted, css)
+		}
+	}
+}
+
+func TestFontFaceCSSDefaultStacks(t *testing.T) {
+	fonts := writeFontFiles(t, "NotoSansJP-Regular.woff2")
+
+	css, err := FontFaceCSS(fonts, "body { color: black }")
+	if err != nil {
+		t.Fatalf("FontFaceCSS: %v", err)
+	}
+	for _, want := range []string{
+		"body {\n    font-family: serif, \"custom-font-1\";\n}",
+		"pre, code {\n    font-family: monospace, \"custom-font-1\";\n}",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("missing default stack %q in\n%s", want, css)
+		}
+	}
+}
+
+func TestFontFaceCSSErrors(t *testing.T) {
+	bitmap := writeFontFiles(t, "font.pcf")[0]
+
+	if css, err := FontFaceCSS(nil, siteFontsCSS); err != nil || css != "" {
+		t.Errorf("no fonts: got %q (err %v), want no CSS", css, err)
+	}
+	if _, err := FontFaceCSS([]string{bitmap}); err == nil || !strings.Contains(err.Error(), "unsupported font file") {
+		t.Errorf("unsupported format: got %v", err)
+	}
+	if _, err := FontFaceCSS([]string{filepath.Join(t.TempDir(), "missing.woff2")}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing file: got %v", err)
+	}
+}
+
+// cjkFontEnv names a TTF or OTF font with CJK glyphs for
+// TestFontFaceCSSRendersCJK; common system fonts are tried without it
+const cjkFontEnv = "GO_BY_EXAMPLE_CJK_FONT"
+
+// cjkFont returns the path of a TTF or OTF font with CJK glyphs, or skips
+// the test if there is none
+func cjkFont(t *testing.T) string {
+	t.Helper()
+	if path := os.Getenv(cjkFontEnv); path != "" {
+		return path
+	}
+	for _, path := range []string{
+		"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+		"/usr/share/fonts/opentype/noto/NotoSansCJKjp-Regular.otf",
+		"/usr/share/fonts/google-droid-sans-fonts/DroidSansFallbackFull.ttf",
+		"/Library/Fonts/Arial Unicode.ttf",
+		"/System/Library/Fonts/Supplemental/Arial Unicode.ttf",
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Skipf("no CJK font found; set $%s to a TTF or OTF font with CJK glyphs", cjkFontEnv)
+	return ""
+}
+
+// browserBin returns the browser to render with, or skips the test if none
+// is installed; Rod's download of a browser is not used in tests
+func browserBin(t *testing.T) string {
+	t.Helper()
+	if path := os.Getenv("ROD_BROWSER_PATH"); path != "" {
+		return path
+	}
+	if path, found := launcher.LookPath(); found {
+		return path
+	}
+	t.Skip("no Chromium found; set $ROD_BROWSER_PATH to render")
+	return ""
+}
+
+// postScriptName returns the PostScript name of a font file, the name a
+// PDF records for the font
+func postScriptName(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := sfnt.Parse(data)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	name, err := font.Name(nil, sfnt.NameIDPostScript)
+	if err != nil {
+		t.Fatalf("no PostScript name in %s: %v", path, err)
+	}
+	return name
+}
+
+// pdfFontNames returns the base names of the fonts of a PDF, without the
+// tag of a subset like "ABCDEF+"
+func pdfFontNames(t *testing.T, pdfPath string) []string {
+	t.Helper()
+	ctx, err := api.ReadContextFile(pdfPath)
+	if err != nil {
+		t.Fatalf("reading %s: %v", pdfPath, err)
+	}
+	var names []string
+	for _, entry := range ctx.Table {
+		d, ok := entry.Object.(types.Dict)
+		if !ok || d.Type() == nil || *d.Type() != "Font" {
+			continue
+		}
+		if name := d.NameEntry("BaseFont"); name != nil {
+			base := *name
+			if i := strings.Index(base, "+"); i == 6 {
+				base = base[i+1:]
+			}
+			names = append(names, base)
+		}
+	}
+	return names
+}
+
+func TestFontFaceCSSRendersCJK(t *testing.T) {
+	if testing.Short() {
+		t.Skip("renders with a browser")
+	}
+	font := cjkFont(t)
+	bin := browserBin(t)
+
+	dir := t.TempDir()
+	htmlPath := filepath.Join(dir, "cjk.html")
+	pdfPath := filepath.Join(dir, "cjk.pdf")
+	page := `<!DOCTYPE html><html><head><meta charset="utf-8"><style>` + siteFontsCSS + `</style></head>` +
+		`<body><h2>你好，世界</h2><p>Go 语言之旅</p><pre><code>fmt.Println("こんにちは")</code></pre></body></html>`
+	if err := os.WriteFile(htmlPath, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	css, err := FontFaceCSS([]string{font}, siteFontsCSS)
+	if err != nil {
+		t.Fatalf("FontFaceCSS: %v", err)
+	}
+
+	browser, closeBrowser, err := NewBrowserWithOptions(BrowserOptions{BinPath: bin, NoSandbox: os.Geteuid() == 0})
+	if err != nil {
+		t.Fatalf("starting the browser: %v", err)
+	}
+	defer closeBrowser()
+	p, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		t.Fatalf("opening a page: %v", err)
+	}
+	defer p.Close()
+	if err := HTMLToPDFOnPageWithOptions(p, htmlPath, pdfPath, PDFOptions{ThemeCSS: css}); err != nil {
+		t.Fatalf("rendering: %v", err)
+	}
+
+	// The CJK text is drawn with the fallback font, which is embedded
+	want := postScriptName(t, font)
+	names := pdfFontNames(t, pdfPath)
+	found := false
+	for _, name := range names {
+		found = found || name == want
+	}
+	if !found {
+		t.Errorf("the PDF embeds the fonts %q, not the fallback font %s", names, want)
+	}
+}
@@ -12,11 +12,14 @@
 //
 // Example usage:
 //
-//	browser := rod.New().MustConnect()
-//	defer browser.MustClose()
+//	browser, closeBrowser, err := htmlpdf.NewBrowser()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer closeBrowser()
 //
 //	// Create an HTML file
-//	err := htmlpdf.CreateHTMLFile("<html><body><h1>Hello World</h1></body></html>", "output.html")
+//	err = htmlpdf.CreateHTMLFile("<html><body><h1>Hello World</h1></body></html>", "output.html")
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -31,22 +34,38 @@ package htmlpdf
 import (
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"go-by-example-book/internal/github"
+	"go-by-example-book/internal/logging"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
-	"github.com/pdfcpu/pdfcpu/pkg/api"
 )
 
+// logger receives all diagnostic output of the package
+var logger = logging.Default()
+
+// SetLogger replaces the logger used for the package's diagnostic output
+//
+// This allows callers to control verbosity and redirect output, e.g. to a
+// file. Passing nil restores the default logger.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Default()
+	}
+	logger = l
+}
+
 // CreateHTMLFile creates an HTML file with the given content
 //
 // This function writes HTML content to a file at the specified path. It's a
 // simple wrapper around os.WriteFile that ensures the content is written with
-// appropriate file permissions (0644).
+// appropriate file permissions (0644). The file is written under a temporary
+// name and then renamed, so runs sharing an output directory never see a
+// partially written file.
 //
 // The function is commonly used to create temporary HTML files that will be
 // converted to PDF, or to save HTML content for later processing.
@@ -66,7 +85,10 @@ import (
 //	    log.Fatal(err)
 //	}
 func CreateHTMLFile(content, filepath string) error {
-	return os.WriteFile(filepath, []byte(content), 0644)
+	return writeFileAtomic(filepath, func(f *os.File) error {
+		_, err := f.WriteString(content)
+		return err
+	})
 }
 
 // HTMLToPDF converts an HTML file to PDF using Rod browser
@@ -79,6 +101,8 @@ func CreateHTMLFile(content, filepath string) error {
 // 3. Waiting for the content to stabilize (CSS and JavaScript execution)
 // 4. Generating a PDF with specified formatting options
 // 5. Saving the PDF to the specified output path
+// 6. Checking that the written PDF is non-empty and valid, rendering again
+// up to two more times if it is not
 //
 // The function uses professional PDF settings including:
 // - Print background enabled for accurate visual representation
@@ -86,7 +110,8 @@ func CreateHTMLFile(content, filepath string) error {
 // - CSS page size preferences for proper layout
 //
 // The browser page is automatically closed after the conversion to prevent
-// resource leaks.
+// resource leaks. To convert many files, HTMLToPDFOnPage avoids opening a new
+// page for every file.
 //
 // Parameters:
 //   - browser: A Rod browser instance that will be used for the conversion
@@ -98,10 +123,13 @@ func CreateHTMLFile(content, filepath string) error {
 //
 // Example:
 //
-//	browser := rod.New().MustConnect()
-//	defer browser.MustClose()
+//	browser, closeBrowser, err := NewBrowser()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer closeBrowser()
 //
-//	err := HTMLToPDF(browser, "input.html", "output.pdf")
+//	err = HTMLToPDF(browser, "input.html", "output.pdf")
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
@@ -110,45 +138,151 @@ func CreateHTMLFile(content, filepath string) error {
 // accessible from the file system. External resources may not load properly
 // in the headless browser environment.
 func HTMLToPDF(browser *rod.Browser, htmlPath, pdfPath string) error {
-	// Convert to absolute path for file:// URL
-	absPath, err := filepath.Abs(htmlPath)
+	return HTMLToPDFWithOptions(browser, htmlPath, pdfPath, PDFOptions{})
+}
+
+// HTMLToPDFWithOptions converts an HTML file to PDF like HTMLToPDF, applying
+// the given rendering options
+//
+// Parameters:
+//   - browser: A Rod browser instance that will be used for the conversion
+//   - htmlPath: The path to the input HTML file
+//   - pdfPath: The path where the output PDF file should be saved
+//   - opts: Rendering options; the zero value renders like HTMLToPDF
+//
+// Returns:
+//   - error: Any error that occurred during the conversion process
+func HTMLToPDFWithOptions(browser *rod.Browser, htmlPath, pdfPath string, opts PDFOptions) error {
+	page, err := browser.Page(proto.TargetCreateTarget{})
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %v", err)
+		return fmt.Errorf("failed to open browser page: %v", err)
 	}
-
-	page := browser.MustPage("file://" + absPath)
 	defer page.Close()
 
-	// Wait for content to load
-	page.MustWaitStable()
-
-	// Generate PDF with default options
-	margin := 0.8 // 20mm in inches
-	stream, err := page.PDF(&proto.PagePrintToPDF{
-		PrintBackground:   true,
-		MarginTop:         &margin,
-		MarginBottom:      &margin,
-		MarginLeft:        &margin,
-		MarginRight:       &margin,
-		PreferCSSPageSize: true,
-	})
+	return HTMLToPDFOnPageWithOptions(page, htmlPath, pdfPath, opts)
+}
+
+// HTMLToPDFOnPage converts an HTML file to PDF using an existing Rod page
+//
+// This function works like HTMLToPDF but navigates the given page to the HTML
+// file instead of opening a new page. Reusing one page for many conversions
+// avoids the cost of constructing a page per file, which dominates the render
+// time of large books.
+//
+// The caller owns the page and is responsible for closing it once all
+// conversions are done.
+//
+// Parameters:
+//   - page: A Rod page that will be navigated to the HTML file
+//   - htmlPath: The path to the input HTML file
+//   - pdfPath: The path where the output PDF file should be saved
+//
+// Returns:
+//   - error: Any error that occurred during the conversion process
+//
+// Example:
+//
+//	page, err := browser.Page(proto.TargetCreateTarget{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer page.Close()
+//
+//	for _, name := range []string{"a", "b"} {
+//	    err := HTMLToPDFOnPage(page, name+".html", name+".pdf")
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func HTMLToPDFOnPage(page *rod.Page, htmlPath, pdfPath string) error {
+	return HTMLToPDFOnPageWithOptions(page, htmlPath, pdfPath, PDFOptions{})
+}
+
+// HTMLToPDFOnPageWithOptions converts an HTML file to PDF like
+// HTMLToPDFOnPage, applying the given rendering options
+//
+// Parameters:
+//   - page: A Rod page that will be navigated to the HTML file
+//   - htmlPath: The path to the input HTML file
+//   - pdfPath: The path where the output PDF file should be saved
+//   - opts: Rendering options; the zero value renders like HTMLToPDFOnPage
+//
+// Returns:
+//   - error: Any error that occurred during the conversion process
+func HTMLToPDFOnPageWithOptions(page *rod.Page, htmlPath, pdfPath string, opts PDFOptions) error {
+	scale, err := opts.scale()
 	if err != nil {
-		return fmt.Errorf("failed to generate PDF: %v", err)
+		return err
 	}
-
-	// Save the PDF to file
-	f, err := os.Create(pdfPath)
+	css, err := opts.css()
 	if err != nil {
-		return fmt.Errorf("failed to create PDF file: %v", err)
+		return err
 	}
-	defer f.Close()
-
-	_, err = io.Copy(f, stream)
+	viewport, err := opts.viewport()
 	if err != nil {
-		return fmt.Errorf("failed to write PDF: %v", err)
+		return err
 	}
 
-	return nil
+	// The override lasts for the page, so a reused page keeps it for every
+	// conversion
+	if viewport != nil {
+		if err := page.SetViewport(viewport); err != nil {
+			return fmt.Errorf("failed to set device scale %g: %v", viewport.DeviceScaleFactor, err)
+		}
+	}
+
+	return renderToFile(rodRenderer{page: page, opts: opts, scale: scale, css: css}, htmlPath, pdfPath)
+}
+
+// renderAttempts is how often a conversion is tried before giving up on an
+// empty or invalid PDF
+const renderAttempts = 3
+
+// renderToFile renders an HTML file with r and writes the PDF to pdfPath
+//
+// page.PDF occasionally returns a stream that yields an empty or truncated
+// file when the browser hiccups; rendering again recovers it, so a PDF that
+// fails checkRenderedPDF is rendered up to renderAttempts times.
+func renderToFile(r pdfRenderer, htmlPath, pdfPath string) error {
+	for attempt := 1; ; attempt++ {
+		stream, err := r.RenderPDF(htmlPath)
+		if err != nil {
+			return err
+		}
+
+		// Save the PDF to file; a concurrent run may render the same example
+		err = writeFileAtomic(pdfPath, func(f *os.File) error {
+			if _, err := io.Copy(f, stream); err != nil {
+				return fmt.Errorf("failed to write PDF: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = checkRenderedPDF(pdfPath)
+		if err == nil {
+			return nil
+		}
+		if attempt == renderAttempts {
+			return fmt.Errorf("%v (gave up after %d attempts)", err, attempt)
+		}
+		logger.Warn("Rendered PDF is unusable, retrying", "file", htmlPath, "attempt", attempt, "err", err)
+	}
+}
+
+// checkRenderedPDF returns an error if a freshly written PDF is empty or
+// cannot be parsed
+func checkRenderedPDF(pdfPath string) error {
+	size, err := fileSize(pdfPath)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return fmt.Errorf("%s is empty", pdfPath)
+	}
+	return validatePDF(pdfPath)
 }
 
 // FileStatus represents the existence status and paths of HTML and PDF files for an example
@@ -159,12 +293,6 @@ type FileStatus struct {
 	PDFPath    string // Full path to the PDF file
 }
 
-// PdfData represents the result of processing an example file
-type PdfData struct {
-	PDFPaths          []string // Updated slice of PDF paths
-	ExamplePageCounts []int    // Updated slice of page counts
-}
-
 // HTMLToPDFParams contains the parameters for HTML to PDF conversion
 type HTMLToPDFParams struct {
 	HTMLContent string       // The HTML content to write to the file
@@ -177,17 +305,19 @@ type HTMLToPDFParams struct {
 // ReceiveOutputFileStatus checks if HTML and PDF files already exist for a given example
 //
 // This function checks the file system to determine if both the HTML and PDF
-// files for a specific example already exist in the output directory.
+// files for a specific example already exist. The HTML files are working
+// files next to the assets, while the PDFs may go to a separate directory.
 //
 // Parameters:
-//   - outputDir: The directory where files are stored
+//   - htmlDir: The directory where the HTML files are stored
+//   - pdfDir: The directory where the PDF files are stored; may equal htmlDir
 //   - filename: The base filename (without extension) for the example
 //
 // Returns:
 //   - FileStatus: A struct containing the existence status and file paths
-func ReceiveOutputFileStatus(outputDir, filename string) FileStatus {
-	htmlPath := filepath.Join(outputDir, filename+".html")
-	pdfPath := filepath.Join(outputDir, filename+".pdf")
+func ReceiveOutputFileStatus(htmlDir, pdfDir, filename string) FileStatus {
+	htmlPath := filepath.Join(htmlDir, filename+".html")
+	pdfPath := filepath.Join(pdfDir, filename+".pdf")
 
 	// Check if both HTML and PDF already exist
 	htmlExists := false
@@ -208,58 +338,32 @@ func ReceiveOutputFileStatus(outputDir, filename string) FileStatus {
 	}
 }
 
-// UpdatePageCountForDownloadedExamples handles the case when both HTML and PDF files already exist
-//
-// This function is called when both the HTML and PDF files for an example already exist
-// in the output directory. It skips the file generation process and instead:
-// 1. Logs that the files are being skipped
-// 2. Adds the PDF path to the list of PDFs to merge
-// 3. Gets the page count of the existing PDF
-// 4. Adds the page count to the tracking slice
-//
-// Parameters:
-//   - ex: The example being processed
-//   - fileStatus: The file status information
-//   - pdfPaths: Slice to append the PDF path to
-//   - examplePageCounts: Slice to append the page count to
-//
-// Returns:
-//   - PdfData: A struct containing the updated PDF paths and page counts
-func UpdatePageCountForDownloadedExamples(ex github.Example, fileStatus FileStatus, pdfPaths []string, examplePageCounts []int) PdfData {
-	fmt.Printf("[SKIPPED] %s (files already exist)\n", ex.Title)
-	pdfPaths = append(pdfPaths, fileStatus.PDFPath)
-
-	// Get page count of existing PDF
-	pageCount, err := api.PageCountFile(fileStatus.PDFPath)
-	if err != nil {
-		log.Printf("[WARNING] Could not get page count for %s: %v", ex.Title, err)
-		pageCount = 1 // fallback assumption
-	}
-	examplePageCounts = append(examplePageCounts, pageCount)
-
-	return PdfData{
-		PDFPaths:          pdfPaths,
-		ExamplePageCounts: examplePageCounts,
-	}
-}
-
 // AddPageInfoToTOC adds page information entries to the Table of Contents HTML
 //
 // This function iterates through the examples and adds formatted list items
 // to the HTML Table of Contents with page numbers and example titles.
 //
+// When categories are given, an <h3> section header is emitted whenever the
+// category changes between consecutive examples, with the examples of the
+// section listed beneath it. Without categories the TOC is a flat list.
+//
 // Parameters:
 //   - examples: Slice of examples to add to the TOC
 //   - startPage: The starting page number for the examples
 //   - examplePageCounts: Slice containing the page count for each example
+//   - categories: Optional category for each example, indexed like examples; nil for a flat TOC
 //
 // Returns:
 //   - string: The HTML content for the Table of Contents entries
-func AddPageInfoToTOC(examples []github.Example, startPage int, examplePageCounts []int) string {
+func AddPageInfoToTOC(examples []github.Example, startPage int, examplePageCounts []int, categories []string) string {
 	var tocContent string
 	currentPage := startPage
 
 	for i, ex := range examples {
+		if i < len(categories) && categories[i] != "" && (i == 0 || categories[i] != categories[i-1]) {
+			// Close the current list and open a new one beneath the section header
+			tocContent += fmt.Sprintf("        </ul>\n        <h3 class=\"toc-section\">%s</h3>\n        <ul>\n", categories[i])
+		}
 		tocContent += fmt.Sprintf("        <li><span class=\"page-number\"><a href=\"#page=%d\">Page %d</a>:</span> %s</li>\n", currentPage, currentPage, ex.Title)
 		if examplePageCounts != nil && i < len(examplePageCounts) {
 			currentPage += examplePageCounts[i] // Add the actual page count for this example
@@ -285,6 +389,45 @@ func CloseTOCList() string {
 </html>`
 }
 
+// IntroOptions controls what BuildIntroHTML puts on the intro page
+//
+// The zero value builds the plain intro with a flat TOC.
+type IntroOptions struct {
+	// Preface is HTML placed on its own pages before the TOC; empty for
+	// none. See CreateBaseHtmlTemplateWithPreface.
+	Preface string
+
+	// Categories is the category of each example, indexed like the
+	// examples; nil for a flat TOC. See AddPageInfoToTOC.
+	Categories []string
+}
+
+// BuildIntroHTML returns the complete intro page with the Table of Contents
+//
+// This composes CreateBaseHtmlTemplateWithPreface, AddPageInfoToTOC and
+// CloseTOCList into one document. The page links site.css, so it must be
+// written next to a copy of it to be styled.
+//
+// Parameters:
+//   - examples: The examples listed in the TOC, in book order
+//   - startPage: The page number of the first example
+//   - pageCounts: The page count of each example; nil numbers the examples
+//     one page apart, e.g. for estimating the length of the intro
+//   - opts: The preface and categories of the intro
+//
+// Returns:
+//   - string: The HTML document of the intro page
+//
+// Example:
+//
+//	html := BuildIntroHTML(examples, 3, pageCounts, IntroOptions{})
+//	err := CreateHTMLFile(html, "intro.html")
+func BuildIntroHTML(examples []github.Example, startPage int, pageCounts []int, opts IntroOptions) string {
+	return CreateBaseHtmlTemplateWithPreface(opts.Preface) +
+		AddPageInfoToTOC(examples, startPage, pageCounts, opts.Categories) +
+		CloseTOCList()
+}
+
 // WriteHTMLAndPDFExp writes HTML content to a file and converts it to PDF
 //
 // This function performs the common operation of writing HTML content to a file
@@ -330,7 +473,7 @@ func CleanupTmpFiles(outputDir string, files []string) {
 		filePath := filepath.Join(outputDir, file)
 		if err := os.Remove(filePath); err != nil {
 			// Log but don't fail - cleanup errors are not critical
-			log.Printf("[INFO] Could not remove temp file %s: %v", filePath, err)
+			logger.Info("Could not remove temp file", "path", filePath, "err", err)
 		}
 	}
 }
@@ -0,0 +1,42 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestMain(m *testing.M) {
+	SetLogger(logging.New(io.Discard, slog.LevelError))
+	os.Exit(m.Run())
+}
+
+// writeTestPDF creates an A4 PDF in a temporary directory whose pages say
+// "Page N" and returns its path
+func writeTestPDF(t *testing.T, pages int) string {
+	t.Helper()
+	var specs []string
+	for i := 1; i <= pages; i++ {
+		specs = append(specs, fmt.Sprintf(`"%d": {"content": {"text": [{"value": "Page %d", "pos": [100, 700], "font": {"name": "Helvetica", "size": 24}}]}}`, i, i))
+	}
+	spec := `{"paper": "A4", "pages": {` + strings.Join(specs, ", ") + `}}`
+
+	var buf bytes.Buffer
+	if err := api.Create(nil, strings.NewReader(spec), &buf, nil); err != nil {
+		t.Fatalf("creating test PDF: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
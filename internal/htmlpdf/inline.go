@@ -0,0 +1,93 @@
+package htmlpdf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// stylesheetLinkPattern matches <link> tags that reference a stylesheet
+	stylesheetLinkPattern = regexp.MustCompile(`<link[^>]*rel=["']?stylesheet["']?[^>]*>`)
+	// hrefPattern extracts the href attribute value of a tag
+	hrefPattern = regexp.MustCompile(`href=["']?([^"'\s>]+)["']?`)
+	// imgSrcPattern matches the src attribute of <img> tags
+	imgSrcPattern = regexp.MustCompile(`(<img[^>]*\ssrc=)["']?([^"'\s>]+)["']?`)
+)
+
+// InlineAssets makes an HTML document self-contained
+//
+// This function replaces references to local assets with their content so
+// the HTML renders the same no matter where the file is moved to:
+// - <link rel="stylesheet"> tags are replaced by a <style> block with the CSS
+// - <img> sources are replaced by base64 data URIs
+//
+// Only relative references that exist in assetDir are inlined; remote URLs,
+// data URIs and missing files are left untouched. Running the function on
+// already inlined content is a no-op.
+//
+// Parameters:
+//   - content: The HTML content to process
+//   - assetDir: The directory containing the referenced assets (e.g. site.css, play.png)
+//
+// Returns:
+//   - string: The HTML content with the assets inlined
+//   - error: Any error that occurred while reading an asset
+//
+// Example:
+//
+//	standalone, err := InlineAssets(htmlContent, "files")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func InlineAssets(content, assetDir string) (string, error) {
+	var firstErr error
+
+	content = stylesheetLinkPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		m := hrefPattern.FindStringSubmatch(tag)
+		if m == nil || !isLocalAsset(m[1]) {
+			return tag
+		}
+		css, err := os.ReadFile(filepath.Join(assetDir, m[1]))
+		if err != nil {
+			if !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("failed to inline %s: %v", m[1], err)
+			}
+			return tag
+		}
+		return "<style>\n" + string(css) + "\n</style>"
+	})
+
+	content = imgSrcPattern.ReplaceAllStringFunc(content, func(attr string) string {
+		m := imgSrcPattern.FindStringSubmatch(attr)
+		if !isLocalAsset(m[2]) {
+			return attr
+		}
+		data, err := os.ReadFile(filepath.Join(assetDir, m[2]))
+		if err != nil {
+			if !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("failed to inline %s: %v", m[2], err)
+			}
+			return attr
+		}
+		mimeType := mime.TypeByExtension(filepath.Ext(m[2]))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		return fmt.Sprintf(`%s"data:%s;base64,%s"`, m[1], mimeType, base64.StdEncoding.EncodeToString(data))
+	})
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return content, nil
+}
+
+// isLocalAsset reports whether a reference points to a file relative to the HTML
+func isLocalAsset(ref string) bool {
+	return !strings.Contains(ref, ":") && !strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "#")
+}
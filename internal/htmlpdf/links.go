@@ -0,0 +1,145 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// pageFragmentPattern matches the "#page=N" fragment the TOC links point to
+var pageFragmentPattern = regexp.MustCompile(`#page=(\d+)$`)
+
+// ResolvePageLinks turns the TOC's "#page=N" links into links to page N
+//
+// The TOC links to the examples with "#page=N" fragments. The intro is
+// rendered as a document of its own, so Chromium cannot resolve them to a
+// page and exports them as URI links to the temporary intro.html instead,
+// which viewers open in a browser. This function rewrites every such link on
+// the first pages of the merged book into a link to page N of the book.
+// Other links, like the examples' links to pkg.go.dev, are left untouched.
+//
+// Parameters:
+//   - pdfPath: The merged book; it is overwritten if a link was rewritten
+//   - pages: The number of leading pages to scan, i.e. the intro page count
+//
+// Returns:
+//   - int: The number of rewritten links
+//   - error: Any error that occurred while reading or writing the PDF
+//
+// Example:
+//
+//	n, err := ResolvePageLinks("temp_with_intro.pdf", introPageCount)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func ResolvePageLinks(pdfPath string, pages int) (int, error) {
+	return ResolvePageLinksRange(pdfPath, 1, pages)
+}
+
+// ResolvePageLinksRange turns the "#page=N" links on pages from through
+// thru into links to page N, like ResolvePageLinks does for the intro
+//
+// This is for pages rendered as documents of their own that do not start
+// the book, like the index after the examples.
+//
+// Parameters:
+//   - pdfPath: The merged book; it is overwritten if a link was rewritten
+//   - from, thru: The 1-based, inclusive range of pages to scan
+//
+// Returns:
+//   - int: The number of rewritten links
+//   - error: Any error that occurred while reading or writing the PDF
+func ResolvePageLinksRange(pdfPath string, from, thru int) (int, error) {
+	ctx, err := api.ReadContextFile(pdfPath)
+	if err != nil {
+		return 0, fmt.Errorf("could not read %s: %v", pdfPath, err)
+	}
+
+	resolved := 0
+	for pageNr := max(from, 1); pageNr <= min(thru, ctx.PageCount); pageNr++ {
+		pageDict, _, _, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return 0, fmt.Errorf("could not read page %d of %s: %v", pageNr, pdfPath, err)
+		}
+
+		annots, err := ctx.DereferenceArray(pageDict["Annots"])
+		if err != nil {
+			return 0, fmt.Errorf("could not read the links on page %d of %s: %v", pageNr, pdfPath, err)
+		}
+		for _, obj := range annots {
+			annot, err := ctx.DereferenceDict(obj)
+			if err != nil || annot == nil {
+				continue
+			}
+			target, ok := pageLinkTarget(ctx, annot)
+			if !ok {
+				continue
+			}
+			_, targetRef, _, err := ctx.PageDict(target, false)
+			if err != nil || targetRef == nil {
+				continue
+			}
+
+			annot.Delete("A")
+			annot.Update("Dest", types.Array{*targetRef, types.Name("Fit")})
+			resolved++
+		}
+	}
+
+	if resolved == 0 {
+		return 0, nil
+	}
+
+	// Write to a new file first, the context may still read from the original
+	tmpPath, err := tempPathFor(pdfPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := api.WriteContextFile(ctx, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("could not write %s: %v", pdfPath, err)
+	}
+	if err := os.Rename(tmpPath, pdfPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("could not replace %s: %v", pdfPath, err)
+	}
+
+	logger.Debug(fmt.Sprintf("%d TOC links", resolved), logging.Tag("LINKS RESOLVED"))
+	return resolved, nil
+}
+
+// pageLinkTarget returns the page a "#page=N" URI link annotation points
+// to, or false if the annotation is no such link or the page does not exist
+func pageLinkTarget(ctx *model.Context, annot types.Dict) (int, bool) {
+	if subtype := annot.NameEntry("Subtype"); subtype == nil || *subtype != "Link" {
+		return 0, false
+	}
+	action, err := ctx.DereferenceDict(annot["A"])
+	if err != nil || action == nil {
+		return 0, false
+	}
+	if s := action.NameEntry("S"); s == nil || *s != "URI" {
+		return 0, false
+	}
+	uri, err := ctx.DereferenceStringOrHexLiteral(action["URI"], model.V10, nil)
+	if err != nil {
+		return 0, false
+	}
+
+	m := pageFragmentPattern.FindStringSubmatch(uri)
+	if m == nil {
+		return 0, false
+	}
+	target, err := strconv.Atoi(m[1])
+	if err != nil || target < 1 || target > ctx.PageCount {
+		return 0, false
+	}
+	return target, true
+}
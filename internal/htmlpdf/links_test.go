@@ -0,0 +1,121 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// addURILinks adds a link annotation for each URI to a page of the PDF
+func addURILinks(t *testing.T, pdfPath string, page int, uris ...string) {
+	t.Helper()
+	for i, uri := range uris {
+		rect := types.NewRectangle(50, float64(700-30*i), 300, float64(720-30*i))
+		link := model.NewLinkAnnotation(*rect, nil, nil, uri, "", 0, 0, model.BSSolid, nil, false)
+		if err := api.AddAnnotationsFile(pdfPath, "", []string{fmt.Sprint(page)}, link, nil, false); err != nil {
+			t.Fatalf("adding link to %s: %v", uri, err)
+		}
+	}
+}
+
+// linkTargets describes the links on a page in annotation order: "page N"
+// for a link to page N, the URI for a URI link
+func linkTargets(t *testing.T, pdfPath string, page int) []string {
+	t.Helper()
+	ctx, err := api.ReadContextFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageRefs := map[int]int{}
+	for nr := 1; nr <= ctx.PageCount; nr++ {
+		_, ref, _, err := ctx.PageDict(nr, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pageRefs[ref.ObjectNumber.Value()] = nr
+	}
+
+	pageDict, _, _, err := ctx.PageDict(page, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annots, err := ctx.DereferenceArray(pageDict["Annots"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var targets []string
+	for _, obj := range annots {
+		annot, err := ctx.DereferenceDict(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dest, ok := annot["Dest"].(types.Array); ok {
+			ref := dest[0].(types.IndirectRef)
+			targets = append(targets, fmt.Sprintf("page %d", pageRefs[ref.ObjectNumber.Value()]))
+			continue
+		}
+		action, err := ctx.DereferenceDict(annot["A"])
+		if err != nil || action == nil {
+			t.Fatalf("link without destination or action: %v", annot)
+		}
+		uri, err := ctx.DereferenceStringOrHexLiteral(action["URI"], model.V10, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, uri)
+	}
+	return targets
+}
+
+func TestResolvePageLinks(t *testing.T) {
+	pdfPath := writeTestPDF(t, 6)
+	const intro = "file:///tmp/book/intro.html"
+	addURILinks(t, pdfPath, 1, intro+"#page=4", "https://pkg.go.dev/fmt", intro+"#page=99", intro+"#page=0")
+	addURILinks(t, pdfPath, 2, intro+"#page=6")
+	addURILinks(t, pdfPath, 5, intro+"#page=2")
+
+	n, err := ResolvePageLinks(pdfPath, 2)
+	if err != nil {
+		t.Fatalf("ResolvePageLinks: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("resolved %d links, want 2", n)
+	}
+
+	// Links to pages outside the book and other URIs are kept
+	want := []string{"page 4", "https://pkg.go.dev/fmt", intro + "#page=99", intro + "#page=0"}
+	if got := linkTargets(t, pdfPath, 1); !slices.Equal(got, want) {
+		t.Errorf("page 1 links: got %q, want %q", got, want)
+	}
+	if got := linkTargets(t, pdfPath, 2); !slices.Equal(got, []string{"page 6"}) {
+		t.Errorf("page 2 links: got %q, want [page 6]", got)
+	}
+	// Pages after the intro are not scanned
+	if got := linkTargets(t, pdfPath, 5); !slices.Equal(got, []string{intro + "#page=2"}) {
+		t.Errorf("page 5 links: got %q, want the URI kept", got)
+	}
+
+	// The index at the end is resolved separately
+	n, err = ResolvePageLinksRange(pdfPath, 5, 6)
+	if err != nil {
+		t.Fatalf("ResolvePageLinksRange: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("resolved %d links on pages 5-6, want 1", n)
+	}
+	if got := linkTargets(t, pdfPath, 5); !slices.Equal(got, []string{"page 2"}) {
+		t.Errorf("page 5 links: got %q, want [page 2]", got)
+	}
+
+	// Resolved links are not touched again
+	if n, err := ResolvePageLinks(pdfPath, 6); err != nil || n != 0 {
+		t.Errorf("second pass resolved %d links (err %v), want 0", n, err)
+	}
+	if pages, err := api.PageCountFile(pdfPath); err != nil || pages != 6 {
+		t.Errorf("book has %d pages (err %v) after resolving, want 6", pages, err)
+	}
+}
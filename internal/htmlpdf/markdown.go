@@ -0,0 +1,92 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// markdownTitlePattern matches a level-1 ATX heading on its own line, e.g.
+// "# Error Wrapping"
+var markdownTitlePattern = regexp.MustCompile(`(?m)^#[ \t]+(.+?)[ \t#]*$`)
+
+// markdown converts Markdown with the GitHub extensions: tables,
+// strikethrough, autolinks and task lists. Raw HTML is passed through, the
+// files are the user's own content like the preface.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+)
+
+// MarkdownTitle returns the title of a Markdown document, the text of its
+// first level-1 heading
+//
+// Parameters:
+//   - src: The Markdown source
+//
+// Returns:
+//   - string: The title; empty if the document has no level-1 heading
+func MarkdownTitle(src string) string {
+	m := markdownTitlePattern.FindStringSubmatch(src)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
+
+// MarkdownToHTML converts a Markdown document into a page like the ones of
+// gobyexample
+//
+// The page links site.css from its own directory like the example pages,
+// so it is styled the same when written next to them. The title becomes
+// the page's <title> and its <h2> heading; the document's first level-1
+// heading is dropped, since it would repeat the title. Code blocks are
+// rendered as plain <pre> blocks without syntax highlighting.
+//
+// Parameters:
+//   - src: The Markdown source
+//   - title: The title of the page, e.g. from MarkdownTitle
+//
+// Returns:
+//   - string: The HTML page
+//   - error: Any error that occurred while converting the Markdown
+//
+// Example:
+//
+//	page, err := MarkdownToHTML("# Error Wrapping\n\nUse `%w` ...", "Error Wrapping")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func MarkdownToHTML(src, title string) (string, error) {
+	if loc := markdownTitlePattern.FindStringIndex(src); loc != nil {
+		src = src[:loc[0]] + src[loc[1]:]
+	}
+
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(src), &body); err != nil {
+		return "", fmt.Errorf("failed to convert Markdown: %v", err)
+	}
+
+	title = html.EscapeString(title)
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>%s</title>
+    <link rel=stylesheet href="site.css">
+  </head>
+  <body>
+    <div class="example lesson">
+      <h2>%s</h2>
+%s
+    </div>
+  </body>
+</html>
+`, title, title, strings.TrimSpace(body.String())), nil
+}
@@ -0,0 +1,168 @@
+package htmlpdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// MergePDFs merges PDF files into a new PDF in the given order
+//
+// Every input is checked before merging, so a missing or corrupt file is
+// reported by name instead of failing somewhere inside pdfcpu. This makes it
+// possible to compose custom collections from already generated per-example
+// PDFs without rendering them again.
+//
+// Parameters:
+//   - inputPaths: The PDF files to merge, in the order they should appear
+//   - outputPath: The path of the merged PDF; it is overwritten if it exists
+//
+// Returns:
+//   - error: Any error that occurred while checking or merging the inputs
+//
+// Example:
+//
+//	err := MergePDFs([]string{"files/goroutines.pdf", "files/channels.pdf"}, "concurrency.pdf")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func MergePDFs(inputPaths []string, outputPath string) error {
+	if len(inputPaths) == 0 {
+		return errors.New("no PDFs to merge")
+	}
+
+	for _, path := range inputPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("cannot merge %s: %v", path, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("cannot merge %s: is a directory", path)
+		}
+		if err := validatePDF(path); err != nil {
+			return fmt.Errorf("cannot merge: %v", err)
+		}
+	}
+
+	conf := model.NewDefaultConfiguration()
+	if err := api.MergeCreateFile(inputPaths, outputPath, false, conf); err != nil {
+		return fmt.Errorf("could not merge PDFs into %s: %v", outputPath, err)
+	}
+
+	logger.Debug(fmt.Sprintf("%d files -> %s", len(inputPaths), outputPath), logging.Tag("MERGED"))
+	return nil
+}
+
+// AppendPDFsParams holds the parameters for appending PDFs to a book
+type AppendPDFsParams struct {
+	BookPDF    string   // The generated book the appendices are appended to
+	Appendices []string // The PDFs to append, in order
+	OutputPDF  string   // Where the combined PDF is written; may be BookPDF to append in place
+}
+
+// AppendPDFs appends externally supplied PDFs, like a reference card, to a
+// generated book
+//
+// The book keeps its bookmarks. Every appendix gets a top-level bookmark
+// titled after its file name, e.g. "Appendix A: reference-card", spanning
+// its pages. Bookmarks inside the appendices are dropped, since their
+// page numbers refer to the appendix on its own.
+//
+// Parameters:
+//   - params: AppendPDFsParams struct containing all necessary parameters
+//
+// Returns:
+//   - error: Any error that occurred while reading, merging or bookmarking
+//     the PDFs
+//
+// Example:
+//
+//	err := AppendPDFs(AppendPDFsParams{
+//	    BookPDF:    "book.pdf",
+//	    Appendices: []string{"reference-card.pdf"},
+//	    OutputPDF:  "book.pdf",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func AppendPDFs(params AppendPDFsParams) error {
+	if len(params.Appendices) == 0 {
+		return errors.New("no appendices to append")
+	}
+
+	bookmarks, err := readBookmarks(params.BookPDF)
+	if err != nil {
+		return err
+	}
+	bookPages, err := api.PageCountFile(params.BookPDF)
+	if err != nil {
+		return fmt.Errorf("could not count pages of %s: %v", params.BookPDF, err)
+	}
+
+	// Each appendix starts right after the pages before it
+	nextPage := bookPages + 1
+	for i, path := range params.Appendices {
+		pages, err := api.PageCountFile(path)
+		if err != nil {
+			return fmt.Errorf("could not count pages of appendix %s: %v", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		bookmarks = append(bookmarks, pdfcpu.Bookmark{
+			Title:    fmt.Sprintf("Appendix %s: %s", appendixLetter(i), name),
+			PageFrom: nextPage,
+			PageThru: nextPage + pages - 1,
+		})
+		nextPage += pages
+	}
+
+	merged, err := tempPathFor(params.OutputPDF)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(merged)
+	if err := MergePDFs(append([]string{params.BookPDF}, params.Appendices...), merged); err != nil {
+		return err
+	}
+
+	conf := model.NewDefaultConfiguration()
+	if err := api.AddBookmarksFile(merged, params.OutputPDF, bookmarks, true, conf); err != nil {
+		return fmt.Errorf("could not add appendix bookmarks: %v", err)
+	}
+
+	logger.Info(fmt.Sprintf("%d appendices, %d pages", len(params.Appendices), nextPage-bookPages-1), logging.Tag("APPENDICES ADDED"))
+	return nil
+}
+
+// readBookmarks returns the bookmarks of a PDF; a PDF without bookmarks
+// yields none
+func readBookmarks(pdfPath string) ([]pdfcpu.Bookmark, error) {
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %v", pdfPath, err)
+	}
+	defer f.Close()
+
+	bookmarks, err := api.Bookmarks(f, model.NewDefaultConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("could not read bookmarks of %s: %v", pdfPath, err)
+	}
+	return bookmarks, nil
+}
+
+// appendixLetter returns the letter of the i-th appendix, counting from
+// zero: A, B, ..., Z, AA, AB, ...
+func appendixLetter(i int) string {
+	letter := string(rune('A' + i%26))
+	if i < 26 {
+		return letter
+	}
+	return appendixLetter(i/26-1) + letter
+}
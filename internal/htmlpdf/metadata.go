@@ -0,0 +1,58 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// titlePattern extracts the content of an HTML document's <title>
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// DocumentTitle returns the human-readable title of an HTML document
+//
+// gobyexample pages are titled "Go by Example: <Title>"; the common prefix
+// is removed. If the document has no usable <title>, fallback is returned.
+//
+// Parameters:
+//   - content: The HTML content of the document
+//   - fallback: The title to use if the document has none, e.g. the filename
+//
+// Returns:
+//   - string: The document's title
+func DocumentTitle(content, fallback string) string {
+	m := titlePattern.FindStringSubmatch(content)
+	if m == nil {
+		return fallback
+	}
+
+	title := strings.TrimSpace(html.UnescapeString(m[1]))
+	title = strings.TrimSpace(strings.TrimPrefix(title, "Go by Example:"))
+	if title == "" {
+		return fallback
+	}
+	return title
+}
+
+// SetPDFTitle sets the Title property of a PDF in place
+//
+// PDF viewers show the property in their title bar and file dialogs, so a
+// per-example PDF stays recognizable when it is used on its own.
+//
+// Parameters:
+//   - pdfPath: The PDF file to update; it is overwritten
+//   - title: The title to set
+//
+// Returns:
+//   - error: Any error that occurred while writing the property
+func SetPDFTitle(pdfPath, title string) error {
+	conf := model.NewDefaultConfiguration()
+	if err := api.AddPropertiesFile(pdfPath, "", map[string]string{"Title": title}, conf); err != nil {
+		return fmt.Errorf("could not set title of %s: %v", pdfPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package htmlpdf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// pdfTitle returns the Title property of a PDF as viewers show it
+func pdfTitle(t *testing.T, pdfPath string) string {
+	t.Helper()
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := api.PDFInfo(f, pdfPath, nil, nil)
+	if err != nil {
+		t.Fatalf("reading PDF info: %v", err)
+	}
+	return info.Title
+}
+
+func TestSetPDFTitle(t *testing.T) {
+	pdfPath := writeTestPDF(t, 2)
+
+	for _, title := range []string{"Worker Pools", "Strings and Runes: ü, 世界 & (parens)"} {
+		if err := SetPDFTitle(pdfPath, title); err != nil {
+			t.Fatalf("SetPDFTitle(%q): %v", title, err)
+		}
+		if got := pdfTitle(t, pdfPath); got != title {
+			t.Errorf("Title is %q, want %q", got, title)
+		}
+	}
+	if pages, err := api.PageCountFile(pdfPath); err != nil || pages != 2 {
+		t.Errorf("the PDF has %d pages (err %v) after setting the title, want 2", pages, err)
+	}
+}
+
+func TestSetPDFTitleMissingFile(t *testing.T) {
+	if err := SetPDFTitle(filepath.Join(t.TempDir(), "missing.pdf"), "Values"); err == nil {
+		t.Error("SetPDFTitle succeeded on a missing file")
+	}
+}
+
+func TestDocumentTitle(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"<html><head><title>Go by Example: Worker Pools</title></head></html>", "Worker Pools"},
+		{"<TITLE>\n  Go by Example: Strings &amp; Runes\n</TITLE>", "Strings & Runes"},
+		{"<title>My Lesson</title>", "My Lesson"},
+		{"<title>Go by Example:</title>", "fallback"},
+		{"<title>  </title>", "fallback"},
+		{"<html><body>No title</body></html>", "fallback"},
+	}
+	for _, tt := range tests {
+		if got := DocumentTitle(tt.content, "fallback"); got != tt.want {
+			t.Errorf("DocumentTitle(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
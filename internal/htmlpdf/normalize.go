@@ -0,0 +1,40 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// NormalizeHTML rewrites HTML content into well-formed markup
+//
+// Chromium tolerates malformed markup like unclosed tags, but how it
+// recovers can shift the layout and with it the page count of an example.
+// Parsing the content with the HTML5 algorithm and rendering the resulting
+// tree closes all elements and quotes all attributes the same way on every
+// run. Normalizing already normalized content returns it unchanged.
+//
+// Parameters:
+//   - content: The HTML content to normalize
+//
+// Returns:
+//   - string: The normalized HTML document
+//   - error: Any error that occurred while parsing or rendering
+//
+// Example:
+//
+//	normalized, err := NormalizeHTML("<p>unclosed <b>bold")
+//	// Returns: "<html><head></head><body><p>unclosed <b>bold</b></p></body></html>"
+func NormalizeHTML(content string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	var b strings.Builder
+	if err := html.Render(&b, doc); err != nil {
+		return "", fmt.Errorf("failed to render HTML: %v", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,66 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// OptimizePDF shrinks a PDF in place by removing redundant resources
+//
+// Every example is rendered as a separate document, so the merged book
+// contains the same fonts and images many times. pdfcpu's optimizer
+// deduplicates them; bookmarks, page mode and document properties are kept.
+//
+// Parameters:
+//   - pdfPath: The PDF file to optimize; it is overwritten
+//
+// Returns:
+//   - int64: The file size in bytes before optimizing
+//   - int64: The file size in bytes after optimizing
+//   - error: Any error that occurred while optimizing
+//
+// Example:
+//
+//	before, after, err := OptimizePDF("book.pdf")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d -> %d bytes\n", before, after)
+func OptimizePDF(pdfPath string) (int64, int64, error) {
+	before, err := fileSize(pdfPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	conf := model.NewDefaultConfiguration()
+	if err := api.OptimizeFile(pdfPath, "", conf); err != nil {
+		return 0, 0, fmt.Errorf("could not optimize %s: %v", pdfPath, err)
+	}
+
+	after, err := fileSize(pdfPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	saved := 0.0
+	if before > 0 {
+		saved = float64(before-after) / float64(before) * 100
+	}
+	logger.Info(fmt.Sprintf("%d -> %d bytes (%.1f%% smaller)", before, after, saved), logging.Tag("OPTIMIZED"))
+
+	return before, after, nil
+}
+
+// fileSize returns the size of a file in bytes
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not stat %s: %v", path, err)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,204 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// PDFOptions controls how an HTML page is rendered to PDF
+//
+// The zero value renders pages unchanged with the default print settings.
+type PDFOptions struct {
+	// ThemeCSS is extra CSS injected into the page before printing. It is
+	// appended after site.css, so its rules override the site's styling.
+	// Empty means no override.
+	ThemeCSS string
+
+	// Scale shrinks or enlarges the rendered page, e.g. 0.9 to fit wide
+	// code blocks on the page without clipping. It must be between MinScale
+	// and MaxScale; zero means DefaultScale.
+	Scale float64
+
+	// Layout arranges the explanation and code of an example; empty means
+	// LayoutSingleColumn
+	Layout Layout
+
+	// WaitSelector is a CSS selector, e.g. "td.code pre.chroma", that must
+	// match an element before the page is printed. Waiting for the page to
+	// become stable is a heuristic that can print code before it has been
+	// highlighted; waiting for the highlighted markup is not. Empty only
+	// waits for the page to become stable.
+	WaitSelector string
+
+	// WaitTimeout is how long to wait for WaitSelector before the conversion
+	// fails; zero means DefaultWaitTimeout
+	WaitTimeout time.Duration
+
+	// DeviceScale is the device pixel ratio the page is rendered with, e.g.
+	// 2 for print quality. Raster content like the play and clipboard icons
+	// is then rendered from a larger bitmap and looks sharper on paper. It
+	// must be between 1 and MaxDeviceScale; zero keeps the browser's ratio.
+	DeviceScale float64
+}
+
+// MaxDeviceScale is the largest PDFOptions.DeviceScale; larger ratios only
+// grow the PDF
+const MaxDeviceScale = 4.0
+
+// deviceScale returns the validated PDFOptions.DeviceScale
+func (o PDFOptions) deviceScale() (float64, error) {
+	if o.DeviceScale != 0 && (o.DeviceScale < 1 || o.DeviceScale > MaxDeviceScale) {
+		return 0, fmt.Errorf("device scale %g out of range, must be between 1 and %g", o.DeviceScale, MaxDeviceScale)
+	}
+	return o.DeviceScale, nil
+}
+
+// viewport returns the device metrics override for PDFOptions.DeviceScale
+//
+// Returns:
+//   - *proto.EmulationSetDeviceMetricsOverride: The override; nil if the
+//     browser's ratio is kept. Its width and height of 0 keep the
+//     browser's viewport size.
+//   - error: An error if the device scale is out of range
+func (o PDFOptions) viewport() (*proto.EmulationSetDeviceMetricsOverride, error) {
+	deviceScale, err := o.deviceScale()
+	if err != nil || deviceScale == 0 {
+		return nil, err
+	}
+	return &proto.EmulationSetDeviceMetricsOverride{DeviceScaleFactor: deviceScale}, nil
+}
+
+// DefaultWaitTimeout is the time PDFOptions.WaitSelector is waited for by default
+const DefaultWaitTimeout = 10 * time.Second
+
+// waitTimeout returns WaitTimeout, or DefaultWaitTimeout if it is not set
+func (o PDFOptions) waitTimeout() time.Duration {
+	if o.WaitTimeout <= 0 {
+		return DefaultWaitTimeout
+	}
+	return o.WaitTimeout
+}
+
+// Layout is a named page layout preset for PDFOptions.Layout
+type Layout string
+
+const (
+	// LayoutSingleColumn keeps the site's own layout
+	LayoutSingleColumn Layout = "single"
+	// LayoutTwoColumn puts the explanation and the code of every step side by
+	// side, which saves vertical space for examples with short code
+	LayoutTwoColumn Layout = "two-column"
+)
+
+// layoutCSS holds the CSS injected for each layout preset
+var layoutCSS = map[Layout]string{
+	LayoutSingleColumn: "",
+	LayoutTwoColumn: `
+@media print {
+    table {
+        width: 100%;
+        border-collapse: collapse;
+    }
+    tr {
+        display: grid;
+        grid-template-columns: 2fr 3fr;
+        column-gap: 1.5em;
+        break-inside: avoid;
+    }
+    td.docs, td.code {
+        display: block;
+        width: auto !important;
+    }
+    td.code pre {
+        white-space: pre-wrap;
+        overflow-wrap: anywhere;
+    }
+}
+`,
+}
+
+// ParseLayout returns the layout preset with the given name
+//
+// Parameters:
+//   - name: The preset name, e.g. "two-column"; empty means LayoutSingleColumn
+//
+// Returns:
+//   - Layout: The layout preset
+//   - error: An error if no preset has that name
+func ParseLayout(name string) (Layout, error) {
+	if name == "" {
+		return LayoutSingleColumn, nil
+	}
+	if _, ok := layoutCSS[Layout(name)]; !ok {
+		return "", fmt.Errorf("unknown layout %q, must be %s or %s", name, LayoutSingleColumn, LayoutTwoColumn)
+	}
+	return Layout(name), nil
+}
+
+// css returns all CSS to inject for the options: the layout preset first,
+// so a theme can still override it
+func (o PDFOptions) css() (string, error) {
+	layout, err := ParseLayout(string(o.Layout))
+	if err != nil {
+		return "", err
+	}
+	return layoutCSS[layout] + o.ThemeCSS, nil
+}
+
+// Limits of PDFOptions.Scale, as supported by Chromium's print
+const (
+	MinScale     = 0.1
+	MaxScale     = 2.0
+	DefaultScale = 1.0
+)
+
+// scale returns the print scale to use, validating PDFOptions.Scale
+//
+// Returns:
+//   - float64: The scale, DefaultScale if none is set
+//   - error: An error if the scale is out of range
+func (o PDFOptions) scale() (float64, error) {
+	if o.Scale == 0 {
+		return DefaultScale, nil
+	}
+	if o.Scale < MinScale || o.Scale > MaxScale {
+		return 0, fmt.Errorf("scale %g out of range, must be between %g and %g", o.Scale, MinScale, MaxScale)
+	}
+	return o.Scale, nil
+}
+
+// PrintThemeCSS is a print-friendly theme for PDFOptions.ThemeCSS
+//
+// The gobyexample site shades code blocks and uses pale syntax colors that
+// wash out on paper and in grayscale prints. This theme drops the code
+// background and switches to dark, high-contrast token colors, keeping
+// keywords bold and comments italic so they remain distinguishable without
+// color.
+const PrintThemeCSS = `
+pre, code, td.code, td.code.leading, td.code.empty {
+    background: #fff !important;
+    color: #000 !important;
+}
+td.code {
+    border-left: 2px solid #bbb;
+}
+.k, .kc, .kd, .kn, .kp, .kr, .kt {
+    color: #000 !important;
+    font-weight: bold;
+}
+.s, .s1, .s2, .sb, .sc, .sd, .se, .sh, .si, .sx, .sr, .ss {
+    color: #1a4d1a !important;
+}
+.c, .c1, .cm, .cp, .cs, .ch, .cpf {
+    color: #555 !important;
+    font-style: italic;
+}
+.m, .mb, .mf, .mh, .mi, .mo, .il {
+    color: #003a80 !important;
+}
+.nb, .nf, .nx, .na, .nc, .nn, .o, .p, .gp, .go {
+    color: #000 !important;
+}
+`
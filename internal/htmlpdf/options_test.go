@@ -0,0 +1,150 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/cdp"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestPDFOptionsViewport(t *testing.T) {
+	tests := []struct {
+		deviceScale float64
+		want        float64 // The device scale factor of the override; 0 for none
+		wantErr     bool
+	}{
+		{0, 0, false},
+		{1, 1, false},
+		{2, 2, false},
+		{1.5, 1.5, false},
+		{MaxDeviceScale, MaxDeviceScale, false},
+		{0.5, 0, true},
+		{-1, 0, true},
+		{MaxDeviceScale + 0.1, 0, true},
+	}
+	for _, tt := range tests {
+		viewport, err := PDFOptions{DeviceScale: tt.deviceScale}.viewport()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("DeviceScale %g: no error", tt.deviceScale)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("DeviceScale %g: %v", tt.deviceScale, err)
+			continue
+		}
+		if tt.want == 0 {
+			if viewport != nil {
+				t.Errorf("DeviceScale %g: got override %+v, want none", tt.deviceScale, viewport)
+			}
+			continue
+		}
+		if viewport == nil {
+			t.Errorf("DeviceScale %g: no override", tt.deviceScale)
+			continue
+		}
+		if viewport.DeviceScaleFactor != tt.want {
+			t.Errorf("DeviceScale %g: override with factor %g", tt.deviceScale, viewport.DeviceScaleFactor)
+		}
+		// Only the ratio is overridden, the viewport size stays the browser's
+		if viewport.Width != 0 || viewport.Height != 0 || viewport.Mobile {
+			t.Errorf("DeviceScale %g: override %+v changes more than the ratio", tt.deviceScale, viewport)
+		}
+	}
+}
+
+func TestHTMLToPDFRejectsDeviceScale(t *testing.T) {
+	// The options are checked before the page is used, so no browser is needed
+	err := HTMLToPDFOnPageWithOptions(nil, "example.html", "example.pdf", PDFOptions{DeviceScale: 8})
+	if err == nil {
+		t.Error("a device scale of 8 was accepted")
+	}
+}
+
+// cdpCall is a call of the Chrome DevTools Protocol
+type cdpCall struct {
+	method string
+	params any
+}
+
+// fakeCDP is a CDPClient that records the calls of a page and answers
+// them from results; methods without a result fail, which ends a conversion
+type fakeCDP struct {
+	calls   []cdpCall
+	results map[string]error
+}
+
+func (c *fakeCDP) Event() <-chan *cdp.Event { return nil }
+
+func (c *fakeCDP) Call(_ context.Context, _, method string, params any) ([]byte, error) {
+	c.calls = append(c.calls, cdpCall{method, params})
+	if err, ok := c.results[method]; ok {
+		if err != nil {
+			return nil, err
+		}
+		return []byte("{}"), nil
+	}
+	return nil, fmt.Errorf("%s is not supported by the fake", method)
+}
+
+// fakePage returns a page whose protocol calls go to client
+func fakePage(client *fakeCDP) *rod.Page {
+	return rod.New().Client(client).PageFromSession("session")
+}
+
+const setDeviceMetrics = "Emulation.setDeviceMetricsOverride"
+
+func TestHTMLToPDFSetsDeviceScale(t *testing.T) {
+	client := &fakeCDP{results: map[string]error{setDeviceMetrics: nil}}
+	err := HTMLToPDFOnPageWithOptions(fakePage(client), "example.html", "example.pdf", PDFOptions{DeviceScale: 2})
+	if err == nil {
+		t.Fatal("the conversion succeeded without a browser")
+	}
+
+	// The override is the first call, before the page is loaded
+	if len(client.calls) == 0 || client.calls[0].method != setDeviceMetrics {
+		t.Fatalf("calls %v, want %s first", client.calls, setDeviceMetrics)
+	}
+	override, ok := client.calls[0].params.(proto.EmulationSetDeviceMetricsOverride)
+	if !ok {
+		t.Fatalf("override params are %T", client.calls[0].params)
+	}
+	if override.DeviceScaleFactor != 2 {
+		t.Errorf("device scale factor %g, want 2", override.DeviceScaleFactor)
+	}
+	for _, call := range client.calls[1:] {
+		if call.method == setDeviceMetrics {
+			t.Errorf("the override was set more than once")
+		}
+	}
+}
+
+func TestHTMLToPDFKeepsDeviceScale(t *testing.T) {
+	client := &fakeCDP{results: map[string]error{setDeviceMetrics: nil}}
+	HTMLToPDFOnPageWithOptions(fakePage(client), "example.html", "example.pdf", PDFOptions{})
+	if len(client.calls) == 0 {
+		t.Fatal("the page was not used")
+	}
+	for _, call := range client.calls {
+		if call.method == setDeviceMetrics {
+			t.Errorf("the device scale was overridden without DeviceScale")
+		}
+	}
+}
+
+func TestHTMLToPDFDeviceScaleError(t *testing.T) {
+	client := &fakeCDP{results: map[string]error{setDeviceMetrics: errors.New("target closed")}}
+	err := HTMLToPDFOnPageWithOptions(fakePage(client), "example.html", "example.pdf", PDFOptions{DeviceScale: 2})
+	if err == nil || !strings.Contains(err.Error(), "failed to set device scale 2: target closed") {
+		t.Errorf("got %v, want the failed override", err)
+	}
+	if len(client.calls) != 1 {
+		t.Errorf("the page was used after the failed override: %v", client.calls)
+	}
+}
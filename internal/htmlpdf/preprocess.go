@@ -0,0 +1,116 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// interactiveImgPattern matches the run/copy button images of gobyexample pages
+	interactiveImgPattern = regexp.MustCompile(`<img[^>]*(?:class=["']?(?:run|copy|play|clipboard)["'\s/>]|src=["']?(?:play|clipboard)\.png)[^>]*>`)
+	// siteScriptPattern matches the <script> tag loading site.js
+	siteScriptPattern = regexp.MustCompile(`<script[^>]*src=["']?site\.js["']?[^>]*>\s*</script>`)
+	// sourceFooterPattern matches a footer added by AddSourceFooter
+	sourceFooterPattern = regexp.MustCompile(`\s*<p class="source-footer"[^>]*>.*?</p>\s*`)
+	// bodyEndPattern matches the closing </body> tag
+	bodyEndPattern = regexp.MustCompile(`(?i)</body>`)
+	// exampleNumberPattern matches a heading added by AddExampleNumber
+	exampleNumberPattern = regexp.MustCompile(`\s*<div class="example-number"[^>]*>.*?</div>\s*`)
+	// titleHeadingPattern matches the opening tag of the <h2> holding an example's title
+	titleHeadingPattern = regexp.MustCompile(`(?i)<h2[\s>]`)
+	// bodyStartPattern matches the opening <body> tag
+	bodyStartPattern = regexp.MustCompile(`(?i)<body[^>]*>`)
+)
+
+// SiteURL is the base URL of the published Go by Example site
+const SiteURL = "https://gobyexample.com/"
+
+// StripInteractiveElements removes elements that only make sense in a browser
+//
+// gobyexample pages contain "run" and "copy" buttons (play.png and
+// clipboard.png) backed by site.js. In a static PDF they are meaningless and
+// show up as pointless icons, so this function removes the button images and
+// the <script> tag that loads site.js. Running it on already stripped content
+// is a no-op.
+//
+// Parameters:
+//   - content: The HTML content to process
+//
+// Returns:
+//   - string: The HTML content without the interactive elements
+func StripInteractiveElements(content string) string {
+	content = interactiveImgPattern.ReplaceAllString(content, "")
+	return siteScriptPattern.ReplaceAllString(content, "")
+}
+
+// AddSourceFooter appends a line linking an example's page on the live site
+//
+// The footer is placed at the end of the <body>, or at the end of the
+// content if it has none, and credits the site while pointing readers to the
+// interactive version. A footer added before is replaced and the whitespace
+// around the footer is reset, so running the function again, also on
+// normalized content, yields the same content.
+//
+// Parameters:
+//   - content: The HTML content of the example
+//   - slug: The example's name on the site, e.g. "worker-pools"
+//
+// Returns:
+//   - string: The HTML content with the footer
+//
+// Example:
+//
+//	content = AddSourceFooter(content, "worker-pools")
+//	// Appends: <p class="source-footer" ...>Online: <a href="https://gobyexample.com/worker-pools">...</a></p>
+func AddSourceFooter(content, slug string) string {
+	content = sourceFooterPattern.ReplaceAllString(content, "")
+
+	url := html.EscapeString(SiteURL + slug)
+	footer := fmt.Sprintf("\n<p class=\"source-footer\" style=\"margin-top: 2em; font-size: 0.8em; color: #666;\">Online: <a href=\"%s\">%s</a></p>\n", url, url)
+
+	ends := bodyEndPattern.FindAllStringIndex(content, -1)
+	if len(ends) == 0 {
+		return strings.TrimRightFunc(content, unicode.IsSpace) + footer
+	}
+	at := ends[len(ends)-1][0]
+	return strings.TrimRightFunc(content[:at], unicode.IsSpace) + footer + content[at:]
+}
+
+// AddExampleNumber adds an "Example N" heading to an example
+//
+// The heading is placed above the example's title, the first <h2> of the
+// page, so a printed page can be found in the numbered bookmarks. Without a
+// title it goes to the start of the <body>, or of the content if it has no
+// body. Like AddSourceFooter, a heading added before is replaced and the
+// whitespace around it is reset, so running the function again yields the
+// same content.
+//
+// Parameters:
+//   - content: The HTML content of the example
+//   - n: The example's number in the book, starting at 1
+//
+// Returns:
+//   - string: The HTML content with the heading
+//
+// Example:
+//
+//	content = AddExampleNumber(content, 12)
+//	// Inserts: <div class="example-number" ...>Example 12</div>
+func AddExampleNumber(content string, n int) string {
+	content = exampleNumberPattern.ReplaceAllString(content, "\n")
+
+	heading := fmt.Sprintf("<div class=\"example-number\" style=\"font-size: 0.9em; color: #666; text-transform: uppercase; letter-spacing: 0.05em;\">Example %d</div>\n", n)
+
+	if loc := titleHeadingPattern.FindStringIndex(content); loc != nil {
+		at := loc[0]
+		return strings.TrimRightFunc(content[:at], unicode.IsSpace) + "\n" + heading + content[at:]
+	}
+	if loc := bodyStartPattern.FindStringIndex(content); loc != nil {
+		at := loc[1]
+		return content[:at] + "\n" + heading + strings.TrimLeftFunc(content[at:], unicode.IsSpace)
+	}
+	return heading + strings.TrimLeftFunc(content, unicode.IsSpace)
+}
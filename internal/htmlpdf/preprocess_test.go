@@ -0,0 +1,86 @@
+package htmlpdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// examplePage is a trimmed gobyexample page with its run and copy buttons
+const examplePage = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>Go by Example: Values</title>
+    <link rel=stylesheet href="site.css">
+  </head>
+  <body>
+    <div class="example" id="values">
+      <h2><a href="./">Go by Example</a>: Values</h2>
+      <table>
+        <tr>
+          <td class="code leading">
+            <a href="https://go.dev/play/p/aGiVohrYqYC"><img title="Run code" src="play.png" class="run" /></a><img title="Copy code" src="clipboard.png" class="copy" />
+          <pre class="chroma"><span class="kn">package</span> <span class="nx">main</span></pre>
+          </td>
+        </tr>
+      </table>
+      <img src="gopher.png" alt="A gopher">
+    </div>
+    <script>
+      var codeLines = [];
+    </script>
+    <script src="site.js" async></script>
+  </body>
+</html>`
+
+func TestStripInteractiveElements(t *testing.T) {
+	got := StripInteractiveElements(examplePage)
+
+	for _, removed := range []string{"play.png", "clipboard.png", `class="run"`, `class="copy"`, "site.js"} {
+		if strings.Contains(got, removed) {
+			t.Errorf("%s was not removed", removed)
+		}
+	}
+	// Everything else is kept, including other images and inline scripts
+	for _, kept := range []string{
+		`<a href="https://go.dev/play/p/aGiVohrYqYC"></a>`,
+		`<img src="gopher.png" alt="A gopher">`,
+		"var codeLines = [];",
+		`<span class="kn">package</span>`,
+		`<link rel=stylesheet href="site.css">`,
+	} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("%s was removed", kept)
+		}
+	}
+
+	// Stripping again changes nothing
+	if again := StripInteractiveElements(got); again != got {
+		t.Errorf("a second run changed the content:\n%s\nwant\n%s", again, got)
+	}
+}
+
+func TestStripInteractiveElementsVariants(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"class only", `<p><img class=run title="Run"></p>`, "<p></p>"},
+		{"source only", `<p><img src='clipboard.png'></p>`, "<p></p>"},
+		{"play class", `<p><img class="play" /></p>`, "<p></p>"},
+		{"quoted script", `<script type="text/javascript" src='site.js'></script>`, ""},
+		{"similar names kept", `<img src="display.png"><img class="runner">`, `<img src="display.png"><img class="runner">`},
+		{"other scripts kept", `<script src="other.js"></script>`, `<script src="other.js"></script>`},
+		{"no buttons", "<p>Plain</p>", "<p>Plain</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StripInteractiveElements(tt.content)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if again := StripInteractiveElements(got); again != got {
+				t.Errorf("a second run changed %q to %q", got, again)
+			}
+		})
+	}
+}
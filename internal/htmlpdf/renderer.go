@@ -0,0 +1,78 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// pdfRenderer prints an HTML file as PDF
+//
+// renderToFile depends on this instead of a Rod page, so writing, checking
+// and retrying the output can be exercised with a fake that returns a stub
+// PDF, without launching a browser. rodRenderer is the implementation used
+// by the HTMLToPDF functions.
+type pdfRenderer interface {
+	// RenderPDF loads the HTML file and returns the printed PDF
+	RenderPDF(htmlPath string) (io.Reader, error)
+}
+
+// rodRenderer renders with a Rod page; scale and css are the validated
+// values of opts
+type rodRenderer struct {
+	page  *rod.Page
+	opts  PDFOptions
+	scale float64
+	css   string
+}
+
+// RenderPDF navigates the page to the HTML file and prints it
+func (r rodRenderer) RenderPDF(htmlPath string) (io.Reader, error) {
+	// Convert to absolute path for file:// URL
+	absPath, err := filepath.Abs(htmlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+
+	if err := r.page.Navigate("file://" + absPath); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", htmlPath, err)
+	}
+
+	// The style tag is appended to <head>, after site.css, so its rules win
+	if r.css != "" {
+		if err := r.page.AddStyleTag("", r.css); err != nil {
+			return nil, fmt.Errorf("failed to apply theme CSS to %s: %v", htmlPath, err)
+		}
+	}
+
+	// Wait for content to load
+	if err := r.page.WaitStable(time.Second); err != nil {
+		return nil, fmt.Errorf("failed waiting for %s to render: %v", htmlPath, err)
+	}
+	if r.opts.WaitSelector != "" {
+		if _, err := r.page.Timeout(r.opts.waitTimeout()).Element(r.opts.WaitSelector); err != nil {
+			return nil, fmt.Errorf("%s did not show %q within %v: %v", htmlPath, r.opts.WaitSelector, r.opts.waitTimeout(), err)
+		}
+	}
+
+	// Generate PDF with default options
+	margin := 0.8 // 20mm in inches
+	scale := r.scale
+	stream, err := r.page.PDF(&proto.PagePrintToPDF{
+		PrintBackground:   true,
+		MarginTop:         &margin,
+		MarginBottom:      &margin,
+		MarginLeft:        &margin,
+		MarginRight:       &margin,
+		PreferCSSPageSize: true,
+		Scale:             &scale,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %v", err)
+	}
+	return stream, nil
+}
@@ -0,0 +1,93 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRenderer returns the given outputs in turn, repeating the last one
+type fakeRenderer struct {
+	outputs [][]byte
+	err     error
+	calls   int
+}
+
+func (r *fakeRenderer) RenderPDF(htmlPath string) (io.Reader, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return bytes.NewReader(r.outputs[min(r.calls, len(r.outputs))-1]), nil
+}
+
+func TestRenderToFile(t *testing.T) {
+	valid, err := os.ReadFile(writeTestPDF(t, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := []byte{}
+	truncated := valid[:len(valid)/2]
+	gaveUp := fmt.Sprintf("gave up after %d attempts", renderAttempts)
+
+	tests := []struct {
+		name      string
+		outputs   [][]byte
+		wantCalls int
+		wantErr   string
+	}{
+		{"valid", [][]byte{valid}, 1, ""},
+		{"empty once", [][]byte{empty, valid}, 2, ""},
+		{"recovers on last attempt", [][]byte{empty, truncated, valid}, renderAttempts, ""},
+		{"always empty", [][]byte{empty}, renderAttempts, gaveUp},
+		{"always truncated", [][]byte{truncated}, renderAttempts, gaveUp},
+		{"valid too late", [][]byte{empty, empty, empty, valid}, renderAttempts, "is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRenderer{outputs: tt.outputs}
+			dir := t.TempDir()
+			pdfPath := filepath.Join(dir, "example.pdf")
+
+			err := renderToFile(r, filepath.Join(dir, "example.html"), pdfPath)
+			if r.calls != tt.wantCalls {
+				t.Errorf("rendered %d times, want %d", r.calls, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderToFile: %v", err)
+			}
+			if got, _ := os.ReadFile(pdfPath); !bytes.Equal(got, valid) {
+				t.Error("the written PDF differs from the rendered one")
+			}
+		})
+	}
+}
+
+func TestRenderToFileRenderError(t *testing.T) {
+	r := &fakeRenderer{err: errors.New("browser crashed")}
+	dir := t.TempDir()
+	pdfPath := filepath.Join(dir, "example.pdf")
+
+	// A failing renderer is not retried; only unusable output is
+	err := renderToFile(r, filepath.Join(dir, "example.html"), pdfPath)
+	if !errors.Is(err, r.err) {
+		t.Errorf("got %v, want the renderer's error", err)
+	}
+	if r.calls != 1 {
+		t.Errorf("rendered %d times, want 1", r.calls)
+	}
+	if _, err := os.Stat(pdfPath); !os.IsNotExist(err) {
+		t.Errorf("a PDF was written despite the error: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes a file through a uniquely named temporary file in
+// the same directory, which then replaces path
+//
+// Runs sharing an output directory may write the same file at the same
+// time. With a temporary file per writer they cannot interleave their
+// writes, and readers see either the old or the new file, never a partial
+// one. The temporary file is removed if anything fails.
+//
+// Parameters:
+//   - path: The file to write
+//   - write: Writes the content to the temporary file
+//
+// Returns:
+//   - error: Any error that occurred while writing or replacing the file
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file for %s: %v", path, err)
+	}
+	tmpPath := f.Name()
+
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+	// CreateTemp restricts the file to its owner; use the usual mode
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not write %s: %v", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// tempPathFor reserves a uniquely named file next to path for writers that
+// need a path instead of an open file, like pdfcpu's
+//
+// The caller must remove or rename the file once done.
+//
+// Returns:
+//   - string: The path of the new, empty file
+//   - error: Any error that occurred while creating the file
+func tempPathFor(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary file for %s: %v", path, err)
+	}
+	f.Close()
+	return f.Name(), nil
+}
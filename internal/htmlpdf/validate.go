@@ -0,0 +1,140 @@
+package htmlpdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"golang.org/x/net/html"
+)
+
+// ValidatePDF checks that a PDF file is well-formed
+//
+// The file is parsed and validated with pdfcpu's relaxed validation, which
+// tolerates the common deviations of real-world PDFs but catches truncated
+// or otherwise corrupt files, e.g. after a browser crash during rendering.
+//
+// Parameters:
+//   - pdfPath: The PDF file to validate
+//
+// Returns:
+//   - error: The validation error, or nil if the file is valid
+//
+// Example:
+//
+//	if err := ValidatePDF("book.pdf"); err != nil {
+//	    log.Fatal(err)
+//	}
+func ValidatePDF(pdfPath string) error {
+	if err := validatePDF(pdfPath); err != nil {
+		return err
+	}
+
+	logger.Info(pdfPath, logging.Tag("VALID"))
+	return nil
+}
+
+// validatePDF validates a PDF like ValidatePDF without logging
+func validatePDF(pdfPath string) error {
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	if err := api.ValidateFile(pdfPath, conf); err != nil {
+		return fmt.Errorf("%s is not a valid PDF: %v", pdfPath, err)
+	}
+	return nil
+}
+
+// maxProblemSnippet is the number of characters of offending content quoted
+// in a ValidateHTML error
+const maxProblemSnippet = 60
+
+// ValidateHTML checks that content is an HTML document that renders a page
+//
+// Browsers render almost anything, so a JSON error blob or a plain text
+// message saved as an example would become a blank or garbled page instead
+// of failing. This function tokenizes the content and rejects it when it
+// has text before the first tag, cannot be tokenized, has no <body> element
+// or an empty one. The error names the first problem found with its line.
+//
+// Parameters:
+//   - content: The HTML content to check
+//
+// Returns:
+//   - error: The first problem found, or nil if the content looks like a page
+//
+// Example:
+//
+//	err := ValidateHTML(`{"message": "API rate limit exceeded"}`)
+//	// Returns: text before the first tag at line 1: "{\"message\": \"API rate limit exceeded\"}"
+func ValidateHTML(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("content is empty")
+	}
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	line := 1
+	sawTag, inBody, sawBody, bodyContent := false, false, false, false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return fmt.Errorf("parse error at line %d: %v", line, err)
+			}
+			break
+		}
+
+		raw := string(z.Raw())
+		switch tt {
+		case html.TextToken:
+			if strings.TrimSpace(raw) == "" {
+				break
+			}
+			if !sawTag {
+				leading := raw[:len(raw)-len(strings.TrimLeft(raw, " \t\r\n"))]
+				return fmt.Errorf("text before the first tag at line %d: %q", line+strings.Count(leading, "\n"), problemSnippet(raw))
+			}
+			if inBody {
+				bodyContent = true
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			sawTag = true
+			name, _ := z.TagName()
+			if string(name) == "body" {
+				inBody, sawBody = true, true
+			} else if inBody {
+				bodyContent = true
+			}
+		case html.EndTagToken:
+			sawTag = true
+			if name, _ := z.TagName(); string(name) == "body" {
+				inBody = false
+			}
+		case html.DoctypeToken, html.CommentToken:
+			sawTag = true
+		}
+		line += strings.Count(raw, "\n")
+	}
+
+	if !sawBody {
+		return fmt.Errorf("no <body> element")
+	}
+	if !bodyContent {
+		return fmt.Errorf("the <body> element is empty")
+	}
+	return nil
+}
+
+// problemSnippet shortens offending content for an error message
+func problemSnippet(s string) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) > maxProblemSnippet {
+		return string(runes[:maxProblemSnippet]) + "..."
+	}
+	return string(runes)
+}
@@ -0,0 +1,69 @@
+package htmlpdf
+
+import (
+	"fmt"
+
+	"go-by-example-book/internal/logging"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Watermark describes a text watermark stamped on every page of a PDF
+type Watermark struct {
+	Text     string  // The watermark text, e.g. "DRAFT"; empty disables watermarking
+	Opacity  float64 // Opacity between 0 (invisible) and 1 (opaque)
+	Rotation float64 // Rotation in degrees, counter-clockwise
+	Color    string  // Fill color as a hex value like "#808080"
+}
+
+// DefaultWatermark returns the watermark settings used when only a text is
+// configured: a light gray, semi-transparent text rotated 45 degrees
+func DefaultWatermark() Watermark {
+	return Watermark{
+		Opacity:  0.3,
+		Rotation: 45,
+		Color:    "#808080",
+	}
+}
+
+// ApplyWatermark stamps a text watermark on every page of a PDF in place
+//
+// The watermark is rendered behind the page content so the examples stay
+// readable. When wm.Text is empty the function does nothing, which makes it
+// safe to call unconditionally as a post-processing step.
+//
+// Parameters:
+//   - pdfPath: The PDF file to watermark; it is overwritten
+//   - wm: The watermark to apply
+//
+// Returns:
+//   - error: Any error that occurred while parsing or applying the watermark
+//
+// Example:
+//
+//	wm := DefaultWatermark()
+//	wm.Text = "DRAFT"
+//	if err := ApplyWatermark("book.pdf", wm); err != nil {
+//	    log.Fatal(err)
+//	}
+func ApplyWatermark(pdfPath string, wm Watermark) error {
+	if wm.Text == "" {
+		return nil
+	}
+
+	desc := fmt.Sprintf("font:Helvetica, scalefactor:0.8, rotation:%g, opacity:%g, fillcolor:%s", wm.Rotation, wm.Opacity, wm.Color)
+	watermark, err := api.TextWatermark(wm.Text, desc, false, false, types.POINTS)
+	if err != nil {
+		return fmt.Errorf("invalid watermark: %v", err)
+	}
+
+	conf := model.NewDefaultConfiguration()
+	if err := api.AddWatermarksFile(pdfPath, "", nil, watermark, conf); err != nil {
+		return fmt.Errorf("could not add watermark: %v", err)
+	}
+
+	logger.Info(wm.Text, logging.Tag("WATERMARK ADDED"))
+	return nil
+}
@@ -0,0 +1,66 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestApplyWatermark(t *testing.T) {
+	pdfPath := writeTestPDF(t, 3)
+	if has, err := api.HasWatermarksFile(pdfPath, nil); err != nil || has {
+		t.Fatalf("the test PDF already has a watermark (err %v)", err)
+	}
+
+	wm := DefaultWatermark()
+	wm.Text = "DRAFT"
+	if err := ApplyWatermark(pdfPath, wm); err != nil {
+		t.Fatalf("ApplyWatermark: %v", err)
+	}
+
+	has, err := api.HasWatermarksFile(pdfPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has {
+		t.Error("the PDF has no watermark")
+	}
+	if pages, err := api.PageCountFile(pdfPath); err != nil || pages != 3 {
+		t.Errorf("the watermarked PDF has %d pages (err %v), want 3", pages, err)
+	}
+	if err := api.ValidateFile(pdfPath, nil); err != nil {
+		t.Errorf("the watermarked PDF is invalid: %v", err)
+	}
+}
+
+func TestApplyWatermarkWithoutText(t *testing.T) {
+	pdfPath := writeTestPDF(t, 1)
+	before, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default settings have no text, so nothing is stamped
+	if err := ApplyWatermark(pdfPath, DefaultWatermark()); err != nil {
+		t.Fatalf("ApplyWatermark: %v", err)
+	}
+	after, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("the PDF was changed without a watermark text")
+	}
+}
+
+func TestApplyWatermarkInvalid(t *testing.T) {
+	pdfPath := writeTestPDF(t, 1)
+	wm := DefaultWatermark()
+	wm.Text = "DRAFT"
+	wm.Color = "grayish"
+	if err := ApplyWatermark(pdfPath, wm); err == nil {
+		t.Error("ApplyWatermark accepted an invalid color")
+	}
+}
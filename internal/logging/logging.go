@@ -0,0 +1,139 @@
+// Package logging provides the leveled logger used throughout the generator.
+//
+// It builds on log/slog and ships a Handler that keeps the bracketed output
+// style of the tool: every record is written as a single line starting with
+// its level in brackets, e.g. "[INFO] Found 150 examples". A record can
+// replace the level label with a more specific tag (such as DOWNLOADED) by
+// adding the attribute returned by Tag; the record's level is still used for
+// filtering, so tagged messages can be silenced like any other.
+//
+// Example usage:
+//
+//	logger := logging.New(os.Stdout, slog.LevelInfo)
+//	logger.Info("Downloading assets...")
+//	logger.Info("site.css", logging.Tag("DOWNLOADED"))
+//	logger.Warn("Failed to download", "file", "play.png", "err", err)
+//
+// Output:
+//
+//	[INFO] Downloading assets...
+//	[DOWNLOADED] site.css
+//	[WARNING] Failed to download file=play.png err=...
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TagKey is the attribute key whose value replaces the level label
+const TagKey = "tag"
+
+// Tag returns an attribute that replaces the bracketed level label of a record
+func Tag(name string) slog.Attr {
+	return slog.String(TagKey, name)
+}
+
+// New returns a logger writing bracketed lines to w for records at or above level
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewHandler(w, level))
+}
+
+// Default returns the logger used before a caller configures one: INFO level on stdout
+func Default() *slog.Logger {
+	return New(os.Stdout, slog.LevelInfo)
+}
+
+// Handler is a slog.Handler producing the tool's bracketed log format
+type Handler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewHandler returns a Handler writing to w for records at or above level
+func NewHandler(w io.Writer, level slog.Leveler) *Handler {
+	return &Handler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled reports whether records at the given level are written
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle formats the record as "[LABEL] message key=value ..." and writes it
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	label := levelLabel(r.Level)
+	var fields []string
+
+	appendAttr := func(a slog.Attr) {
+		if a.Key == TagKey {
+			label = a.Value.String()
+			return
+		}
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		key := a.Key
+		if len(h.groups) > 0 {
+			key = strings.Join(h.groups, ".") + "." + key
+		}
+		fields = append(fields, fmt.Sprintf("%s=%v", key, a.Value.Resolve()))
+	}
+
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(a)
+		return true
+	})
+
+	line := fmt.Sprintf("[%s] %s", label, r.Message)
+	if len(fields) > 0 {
+		line += " " + strings.Join(fields, " ")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.w, line)
+	return err
+}
+
+// WithAttrs returns a Handler that adds the given attributes to every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup returns a Handler that prefixes subsequent attribute keys with name
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
+
+// levelLabel returns the bracketed label used for a level
+func levelLabel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
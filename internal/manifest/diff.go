@@ -0,0 +1,70 @@
+package manifest
+
+import "sort"
+
+// Diff lists the examples that differ between two manifests
+//
+// All lists are sorted by filename.
+type Diff struct {
+	Added   []string // Examples recorded only in the newer manifest
+	Removed []string // Examples recorded only in the older manifest
+	Changed []string // Examples recorded in both, with different content hashes
+}
+
+// Empty reports whether the two compared manifests record the same examples
+// with the same content
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare reports which examples were added, removed or changed between
+// two builds
+//
+// This is the basis for a changelog of the book, e.g. to see which examples
+// appeared upstream since the last build. A nil manifest is treated as
+// empty, so comparing against the first build lists every example as added.
+//
+// Parameters:
+//   - previous: The manifest of the older build
+//   - current: The manifest of the newer build
+//
+// Returns:
+//   - Diff: The added, removed and changed examples
+//
+// Example:
+//
+//	previous, _ := manifest.Load("old/manifest.json")
+//	current, _ := manifest.Load("files/manifest.json")
+//	diff := manifest.Compare(previous, current)
+//	for _, file := range diff.Added {
+//	    fmt.Println("new example:", file)
+//	}
+func Compare(previous, current *Manifest) Diff {
+	if previous == nil {
+		previous = New()
+	}
+	if current == nil {
+		current = New()
+	}
+
+	var diff Diff
+	for file, entry := range current.Examples {
+		old, ok := previous.Examples[file]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, file)
+		case old.ContentHash != entry.ContentHash:
+			diff.Changed = append(diff.Changed, file)
+		}
+	}
+	for file := range previous.Examples {
+		if _, ok := current.Examples[file]; !ok {
+			diff.Removed = append(diff.Removed, file)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
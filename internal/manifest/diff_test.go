@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// manifestOf returns a manifest recording each example with the given content
+func manifestOf(contents map[string]string) *Manifest {
+	m := New()
+	for file, content := range contents {
+		m.Set(file, Entry{ContentHash: HashContent(content), PageCount: 1})
+	}
+	return m
+}
+
+func TestCompare(t *testing.T) {
+	previous := manifestOf(map[string]string{
+		"values":      "v1",
+		"closures":    "c1",
+		"goroutines":  "g1",
+		"hello_world": "h1",
+		"enums":       "e1",
+	})
+	current := manifestOf(map[string]string{
+		"values":      "v1",
+		"closures":    "c2",
+		"hello_world": "h2",
+		"generics":    "x1",
+		"iterators":   "i1",
+	})
+	// Only the content hash decides whether an example changed
+	entry, _ := current.Get("values")
+	entry.PageCount = 3
+	current.Set("values", entry)
+
+	diff := Compare(previous, current)
+	if want := []string{"generics", "iterators"}; !slices.Equal(diff.Added, want) {
+		t.Errorf("Added = %q, want %q", diff.Added, want)
+	}
+	if want := []string{"enums", "goroutines"}; !slices.Equal(diff.Removed, want) {
+		t.Errorf("Removed = %q, want %q", diff.Removed, want)
+	}
+	if want := []string{"closures", "hello_world"}; !slices.Equal(diff.Changed, want) {
+		t.Errorf("Changed = %q, want %q", diff.Changed, want)
+	}
+	if diff.Empty() {
+		t.Error("Empty() = true for a non-empty diff")
+	}
+
+	// Swapping the manifests swaps added and removed
+	reverse := Compare(current, previous)
+	if !slices.Equal(reverse.Added, diff.Removed) || !slices.Equal(reverse.Removed, diff.Added) ||
+		!slices.Equal(reverse.Changed, diff.Changed) {
+		t.Errorf("reverse diff %+v does not mirror %+v", reverse, diff)
+	}
+}
+
+func TestCompareEmpty(t *testing.T) {
+	m := manifestOf(map[string]string{"values": "v1", "closures": "c1"})
+
+	tests := []struct {
+		name              string
+		previous, current *Manifest
+		added, removed    []string
+	}{
+		{"same manifest", m, m, nil, nil},
+		{"identical content", m, manifestOf(map[string]string{"values": "v1", "closures": "c1"}), nil, nil},
+		{"both nil", nil, nil, nil, nil},
+		{"nil and empty", nil, New(), nil, nil},
+		{"first build", nil, m, []string{"closures", "values"}, nil},
+		{"everything removed", m, nil, nil, []string{"closures", "values"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Compare(tt.previous, tt.current)
+			if !slices.Equal(diff.Added, tt.added) || !slices.Equal(diff.Removed, tt.removed) || diff.Changed != nil {
+				t.Errorf("got %+v, want added %q and removed %q", diff, tt.added, tt.removed)
+			}
+			if want := tt.added == nil && tt.removed == nil; diff.Empty() != want {
+				t.Errorf("Empty() = %v, want %v", diff.Empty(), want)
+			}
+		})
+	}
+}
+
+func TestCompareLoaded(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	if err := manifestOf(map[string]string{"values": "v1", "closures": "c1"}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := Compare(previous, manifestOf(map[string]string{"values": "v1", "closures": "c1"})); !diff.Empty() {
+		t.Errorf("a saved and loaded manifest differs: %+v", diff)
+	}
+	if diff := Compare(missing, previous); !slices.Equal(diff.Added, []string{"closures", "values"}) {
+		t.Errorf("against a missing manifest: got %+v, want every example added", diff)
+	}
+}
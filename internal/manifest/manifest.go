@@ -0,0 +1,140 @@
+// Package manifest records what was generated for each example so repeated
+// builds can tell which examples need to be regenerated.
+//
+// The manifest is a small JSON file stored in the output directory. For every
+// example it keeps the upstream source URL, a SHA-256 hash of the HTML content
+// that was rendered, and the page count of the generated PDF. On the next run
+// the hash of the current content is compared against the recorded one; only
+// examples whose content changed (or that are new) have to be re-rendered.
+// The current content is what upstream serves: github.GetGitHubFiles checks
+// every local copy against upstream before it is hashed.
+//
+// Example usage:
+//
+//	m, err := manifest.Load("files/manifest.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	hash := manifest.HashContent(content)
+//	if m.Changed("hello_world", hash) {
+//	    // regenerate the PDF
+//	}
+//	m.Set("hello_world", manifest.Entry{SourceURL: url, ContentHash: hash, PageCount: 2})
+//	err = m.Save("files/manifest.json")
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FileName is the default name of the manifest file inside the output directory
+const FileName = "manifest.json"
+
+// Entry describes the generated output for a single example
+type Entry struct {
+	SourceURL   string `json:"sourceUrl"`   // The upstream URL the content was downloaded from
+	ContentHash string `json:"contentHash"` // SHA-256 hex digest of the rendered HTML content
+	PageCount   int    `json:"pageCount"`   // Number of pages in the generated PDF
+
+	// LastModified is when the example's content last changed: the time
+	// upstream reported, or else the build that first saw the content;
+	// zero if unknown
+	LastModified time.Time `json:"lastModified,omitzero"`
+}
+
+// Manifest maps example filenames to the entries recorded for them
+type Manifest struct {
+	Examples map[string]Entry `json:"examples"`
+}
+
+// New returns an empty manifest ready for use
+func New() *Manifest {
+	return &Manifest{Examples: make(map[string]Entry)}
+}
+
+// Load reads a manifest from the given path
+//
+// A missing file is not an error: it simply means no previous build was
+// recorded, so an empty manifest is returned and every example is treated
+// as changed.
+//
+// Parameters:
+//   - path: The path to the manifest JSON file
+//
+// Returns:
+//   - *Manifest: The loaded (or empty) manifest
+//   - error: Any error that occurred while reading or parsing the file
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	m := New()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	if m.Examples == nil {
+		m.Examples = make(map[string]Entry)
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest to the given path as indented JSON
+//
+// Parameters:
+//   - path: The path where the manifest should be written
+//
+// Returns:
+//   - error: Any error that occurred while encoding or writing the file
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Get returns the entry recorded for an example and whether one exists
+func (m *Manifest) Get(file string) (Entry, bool) {
+	entry, ok := m.Examples[file]
+	return entry, ok
+}
+
+// Set records the entry for an example, replacing any previous one
+func (m *Manifest) Set(file string, entry Entry) {
+	m.Examples[file] = entry
+}
+
+// Changed reports whether an example must be regenerated
+//
+// An example is considered changed when it has no entry in the manifest or
+// when the recorded content hash differs from the given one.
+//
+// Parameters:
+//   - file: The sanitized filename of the example
+//   - contentHash: The hash of the current content, as returned by HashContent
+//
+// Returns:
+//   - bool: true if the example is new or its content changed
+func (m *Manifest) Changed(file, contentHash string) bool {
+	entry, ok := m.Examples[file]
+	return !ok || entry.ContentHash != contentHash
+}
+
+// HashContent returns the SHA-256 hex digest of the given content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
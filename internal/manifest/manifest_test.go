@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	previous := New()
+	previous.Set("values", Entry{
+		SourceURL:    "https://example.com/values",
+		ContentHash:  HashContent("<html>values</html>"),
+		PageCount:    2,
+		LastModified: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err := previous.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The decision of the next build is made against the saved manifest
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    bool
+	}{
+		{"same upstream content", "values", "<html>values</html>", false},
+		{"changed upstream content", "values", "<html>values, revised</html>", true},
+		{"whitespace counts", "values", "<html>values</html>\n", true},
+		{"new example", "closures", "<html>closures</html>", true},
+	}
+	for _, tt := range tests {
+		if got := m.Changed(tt.file, HashContent(tt.content)); got != tt.want {
+			t.Errorf("%s: Changed(%q) = %v, want %v", tt.name, tt.file, got, tt.want)
+		}
+	}
+
+	// Recording the new content makes it unchanged for the build after
+	m.Set("values", Entry{ContentHash: HashContent("<html>values, revised</html>"), PageCount: 3})
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if m.Changed("values", HashContent("<html>values, revised</html>")) {
+		t.Error("the recorded content is reported as changed")
+	}
+	if !m.Changed("values", HashContent("<html>values</html>")) {
+		t.Error("the content before the change is reported as unchanged")
+	}
+}
+
+func TestChangedMissingManifest(t *testing.T) {
+	m, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Changed("values", HashContent("<html>values</html>")) {
+		t.Error("without a previous build an example is reported as unchanged")
+	}
+}
@@ -0,0 +1,138 @@
+package naming
+
+import "sort"
+
+// Index finds the names matching a filename without comparing it to every
+// name
+//
+// Matching one filename against n names with Matches extracts and compares
+// the words of all n names. The index extracts them once and keeps an
+// inverted index from every word to the names containing it. A name can only
+// reach a positive overlap if it shares a word with the filename, so only
+// those names and the names that are equal apart from case, extension and
+// separators (see Matches) are compared. The results are the same as calling
+// Matches for every name.
+//
+// An Index is not modified after NewIndex and is safe for concurrent use.
+type Index struct {
+	names  []string
+	words  [][]string       // Words of each name, see ExtractWords
+	byWord map[string][]int // Indexes of the names containing a word
+	byName map[string][]int // Indexes of the names with the same normalized name
+}
+
+// NewIndex indexes the given names for matching
+//
+// Parameters:
+//   - names: The names to match against, e.g. the local HTML filenames
+//
+// Returns:
+//   - *Index: The index; the order of names is kept for the results
+//
+// Example:
+//
+//	index := NewIndex([]string{"closing_channels.html", "hello_world.html"})
+//	index.Matches("closing-channels", 0.7, 1, MetricJaccard)
+//	// Returns: ["closing_channels.html"]
+func NewIndex(names []string) *Index {
+	index := &Index{
+		names:  names,
+		words:  make([][]string, len(names)),
+		byWord: make(map[string][]int),
+		byName: make(map[string][]int),
+	}
+	for i, name := range names {
+		index.words[i] = ExtractWords(name)
+		for _, word := range index.words[i] {
+			index.byWord[word] = append(index.byWord[word], i)
+		}
+		normalized := normalizeName(name)
+		index.byName[normalized] = append(index.byName[normalized], i)
+	}
+	return index
+}
+
+// Matches returns the indexed names that Matches reports as referring to
+// the same example as original
+//
+// With a threshold of 0 or less every name with enough words matches, so
+// all names are compared like without an index.
+//
+// Parameters:
+//   - original: The upstream filename, e.g. "closing-channels"
+//   - threshold, minWords, metric: As for Matches
+//
+// Returns:
+//   - []string: The matching names in the order they were indexed; nil if none matches
+func (ix *Index) Matches(original string, threshold float64, minWords int, metric Metric) []string {
+	originalWords := ExtractWords(original)
+
+	candidates := make(map[int]bool)
+	if threshold <= 0 {
+		for i := range ix.names {
+			candidates[i] = true
+		}
+	} else {
+		for _, word := range originalWords {
+			for _, i := range ix.byWord[word] {
+				candidates[i] = true
+			}
+		}
+		for _, i := range ix.byName[normalizeName(original)] {
+			candidates[i] = true
+		}
+	}
+
+	var matched []int
+	for i := range candidates {
+		if matchWords(original, ix.names[i], originalWords, ix.words[i], threshold, minWords, metric) {
+			matched = append(matched, i)
+		}
+	}
+	sort.Ints(matched)
+
+	var names []string
+	for _, i := range matched {
+		names = append(names, ix.names[i])
+	}
+	return names
+}
+
+// Best returns the indexed name with the highest word overlap with original
+//
+// This is for explaining a match decision, e.g. to tune the threshold: it
+// ignores the threshold and the minimum word count of Matches, which may
+// still reject the name or accept another one with an equal normalized
+// name. Only names sharing a word with original have a positive overlap; of
+// names with the same overlap, the first indexed one is returned.
+//
+// Parameters:
+//   - original: The upstream filename, e.g. "closing-channels"
+//   - metric: How the overlap is measured; empty means MetricJaccard
+//
+// Returns:
+//   - string: The best scoring name; empty if no name shares a word with original
+//   - float64: Its overlap (0.0-1.0)
+//
+// Example:
+//
+//	index := NewIndex([]string{"channels.html", "closing_channels.html"})
+//	name, overlap := index.Best("closing-channels", MetricJaccard)
+//	// Returns: "closing_channels.html", 1.0
+func (ix *Index) Best(original string, metric Metric) (string, float64) {
+	originalWords := ExtractWords(original)
+
+	best, bestOverlap := -1, 0.0
+	for _, word := range originalWords {
+		for _, i := range ix.byWord[word] {
+			overlap := metric.Overlap(originalWords, ix.words[i])
+			if overlap > bestOverlap || (overlap == bestOverlap && i < best) {
+				best, bestOverlap = i, overlap
+			}
+		}
+	}
+	if best < 0 {
+		return "", 0.0
+	}
+	return ix.names[best], bestOverlap
+}
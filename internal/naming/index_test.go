@@ -0,0 +1,59 @@
+package naming
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// naiveMatches matches original against every name with Matches, the way
+// the local files were matched before the index
+func naiveMatches(names []string, original string, threshold float64, minWords int, metric Metric) []string {
+	var matched []string
+	for _, name := range names {
+		if Matches(original, name, threshold, minWords, metric) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+func TestIndexMatchesNaiveLoop(t *testing.T) {
+	// Local files of a previous build plus a few with extra or fewer words,
+	// so some upstream names match several files or none
+	local := append(localHTMLNames(gobyexampleNames),
+		"closing_channels_explained.html", "maps_and_slices.html", "go_by_example.html",
+		"channels.html", "worker.html", "time.html")
+	index := NewIndex(local)
+
+	upstream := append(append([]string{}, gobyexampleNames...), "go-by-example", "unknown-example", "channel")
+	for _, metric := range []Metric{MetricJaccard, MetricCoefficient} {
+		for _, threshold := range []float64{0, 0.3, 0.5, 0.7, 1} {
+			for _, minWords := range []int{0, 1, 2, 3} {
+				for _, name := range upstream {
+					want := naiveMatches(local, name, threshold, minWords, metric)
+					got := index.Matches(name, threshold, minWords, metric)
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%s, threshold %v, minWords %d, metric %s: got %v, want %v",
+							name, threshold, minWords, metric, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkIndexMatches(b *testing.B) {
+	index := NewIndex(localHTMLNames(gobyexampleNames))
+	for b.Loop() {
+		for _, name := range gobyexampleNames {
+			index.Matches(name, 0.7, DefaultMinWords, MetricJaccard)
+		}
+	}
+}
+
+func ExampleIndex_Matches() {
+	index := NewIndex([]string{"closing_channels.html", "hello_world.html"})
+	fmt.Println(index.Matches("closing-channels", 0.7, 1, MetricJaccard))
+	// Output: [closing_channels.html]
+}
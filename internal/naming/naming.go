@@ -12,13 +12,17 @@
 // Example usage:
 //
 //	words := naming.ExtractWords("hello-world-example.html")
-//	// Returns: ["hello", "world", "example"]
+//	// Returns: ["hello", "world"]
 //
 //	similarity := naming.WordOverlap(words1, words2)
 //	// Returns: float64 between 0.0 and 1.0
 package naming
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // ExtractWords splits a filename into meaningful words
 //
@@ -27,14 +31,17 @@ import "strings"
 // 2. Splitting on common separators (hyphens, underscores, spaces, colons)
 // 3. Converting to lowercase and trimming whitespace
 // 4. Filtering out common words like "go", "by", "example" and empty strings
+// 5. Dropping repeated words, keeping the first occurrence
 //
-// The result is a slice of meaningful words that can be used for comparison
-// and matching purposes.
+// The result is a slice of distinct, meaningful words in the order they
+// appear in the filename, which can be used for comparison and matching
+// purposes.
 //
 // Example:
 //
-//	ExtractWords("hello-world-example.html") -> ["hello", "world", "example"]
+//	ExtractWords("hello-world-example.html") -> ["hello", "world"]
 //	ExtractWords("go_by_example_test") -> ["test"]
+//	ExtractWords("map-map-example") -> ["map"]
 func ExtractWords(filename string) []string {
 	// Remove file extension
 	filename = strings.TrimSuffix(filename, ".html")
@@ -44,18 +51,38 @@ func ExtractWords(filename string) []string {
 		return r == '-' || r == '_' || r == ' ' || r == ':'
 	})
 
-	// Filter out empty strings and common words
+	// Filter out empty strings, common words and repetitions
 	var result []string
+	seen := make(map[string]bool)
 	for _, word := range words {
 		word = strings.ToLower(strings.TrimSpace(word))
-		if word != "" && word != "go" && word != "by" && word != "example" {
-			result = append(result, word)
+		if word == "" || word == "go" || word == "by" || word == "example" || seen[word] {
+			continue
 		}
+		seen[word] = true
+		result = append(result, word)
 	}
 
 	return result
 }
 
+// WordSet returns the words of a filename as a set
+//
+// This is ExtractWords for callers that only need set semantics, e.g. to
+// test whether a filename contains a word.
+//
+// Example:
+//
+//	_, ok := WordSet("closing-channels")["channels"] // ok == true
+func WordSet(filename string) map[string]struct{} {
+	words := ExtractWords(filename)
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
 // WordOverlap calculates the overlap ratio between two word sets
 //
 // This function uses Jaccard similarity to measure how similar two sets of words are.
@@ -69,12 +96,21 @@ func ExtractWords(filename string) []string {
 // This is useful for determining if two filenames refer to the same content
 // even when they use different naming conventions.
 //
+// A nil slice is treated like an empty one. If either slice is empty the
+// result is 0.0, also when both are: two names without any words give no
+// evidence that they refer to the same example. Duplicate words count once.
+// The function never panics, and the result is always within [0.0, 1.0].
+//
 // Example:
 //
+//	WordOverlap(nil, []string{"hello"}) // Returns 0.0
+//	WordOverlap(nil, nil)               // Returns 0.0
+//
 //	words1 := []string{"hello", "world", "example"}
 //	words2 := []string{"hello", "world", "test"}
 //	overlap := WordOverlap(words1, words2) // Returns 0.5
 func WordOverlap(originalWords, existingWords []string) float64 {
+	// Also covers nil slices and keeps the union below from being zero
 	if len(originalWords) == 0 || len(existingWords) == 0 {
 		return 0.0
 	}
@@ -106,3 +142,137 @@ func WordOverlap(originalWords, existingWords []string) float64 {
 
 	return float64(overlappingWords) / float64(totalUniqueWords)
 }
+
+// WordOverlapCoefficient calculates the overlap coefficient of two word sets
+//
+// Unlike WordOverlap, the shared words are divided by the size of the
+// smaller set: |A ∩ B| / min(|A|, |B|). Extra descriptive words in one of the
+// names therefore do not lower the result, so "closing-channels" and
+// "closing_channels_explained" give 1.0 instead of 0.67. In turn, a short
+// name is contained in many longer ones, so combine it with a minimum word
+// count (see Matches).
+//
+// Nil slices are treated like empty ones; if either slice is empty the
+// result is 0.0. Duplicate words count once. The result is always within
+// [0.0, 1.0].
+//
+// Example:
+//
+//	words1 := []string{"closing", "channels"}
+//	words2 := []string{"closing", "channels", "explained"}
+//	overlap := WordOverlapCoefficient(words1, words2) // Returns 1.0
+func WordOverlapCoefficient(originalWords, existingWords []string) float64 {
+	if len(originalWords) == 0 || len(existingWords) == 0 {
+		return 0.0
+	}
+
+	originalWordSet := make(map[string]bool)
+	for _, word := range originalWords {
+		originalWordSet[word] = true
+	}
+	existingWordSet := make(map[string]bool)
+	for _, word := range existingWords {
+		existingWordSet[word] = true
+	}
+
+	overlappingWords := 0
+	for word := range originalWordSet {
+		if existingWordSet[word] {
+			overlappingWords++
+		}
+	}
+
+	return float64(overlappingWords) / float64(min(len(originalWordSet), len(existingWordSet)))
+}
+
+// Metric selects how Matches measures the overlap of two names
+type Metric string
+
+const (
+	// MetricJaccard uses WordOverlap, the shared words relative to all words
+	MetricJaccard Metric = "jaccard"
+	// MetricCoefficient uses WordOverlapCoefficient, the shared words
+	// relative to the shorter name, which tolerates extra words in one name
+	MetricCoefficient Metric = "coefficient"
+)
+
+// ParseMetric returns the metric with the given name
+//
+// Parameters:
+//   - name: The metric name, e.g. "coefficient"; empty means MetricJaccard
+//
+// Returns:
+//   - Metric: The metric
+//   - error: An error if no metric has that name
+func ParseMetric(name string) (Metric, error) {
+	switch Metric(name) {
+	case "", MetricJaccard:
+		return MetricJaccard, nil
+	case MetricCoefficient:
+		return MetricCoefficient, nil
+	default:
+		return "", fmt.Errorf("unknown metric %q, must be %s or %s", name, MetricJaccard, MetricCoefficient)
+	}
+}
+
+// Overlap measures the overlap of two word lists with the metric; an empty
+// or unknown metric uses WordOverlap
+func (m Metric) Overlap(originalWords, existingWords []string) float64 {
+	if m == MetricCoefficient {
+		return WordOverlapCoefficient(originalWords, existingWords)
+	}
+	return WordOverlap(originalWords, existingWords)
+}
+
+// DefaultMinWords is the default minimum number of words both names need
+// for Matches to trust their word overlap
+const DefaultMinWords = 1
+
+// nameSeparators matches the runs of characters that separate the words of a
+// filename
+var nameSeparators = regexp.MustCompile(`[^\w]+`)
+
+// Matches reports whether an existing filename refers to the same example
+// as an original filename
+//
+// Names with few words make the overlap unreliable: two single-word names
+// sharing their only word have an overlap of 1.0, and a name consisting only
+// of common words has no words at all. If either name has fewer than
+// minWords words, the names must therefore be equal apart from case, the
+// .html extension and separators ("hello-world" equals "hello_world.html").
+// Otherwise their overlap measured with metric must reach threshold.
+//
+// Parameters:
+//   - original: The upstream filename, e.g. "hello-world"
+//   - existing: The local filename, e.g. "hello_world.html"
+//   - threshold: The minimum word overlap (0.0-1.0)
+//   - minWords: The minimum number of words of both names to use the overlap
+//   - metric: How the overlap is measured; empty means MetricJaccard
+//
+// Returns:
+//   - bool: true if both names refer to the same example
+//
+// Example:
+//
+//	Matches("closing-channels", "closing_channels.html", 0.7, 1, MetricJaccard)                 // Returns: true
+//	Matches("maps", "maps_and_slices.html", 0.3, 2, MetricJaccard)                             // Returns: false
+//	Matches("closing-channels", "closing_channels_explained.html", 0.7, 1, MetricCoefficient) // Returns: true
+func Matches(original, existing string, threshold float64, minWords int, metric Metric) bool {
+	return matchWords(original, existing, ExtractWords(original), ExtractWords(existing), threshold, minWords, metric)
+}
+
+// matchWords is Matches for names whose words were already extracted
+func matchWords(original, existing string, originalWords, existingWords []string, threshold float64, minWords int, metric Metric) bool {
+	minWords = max(minWords, 1) // Names without words never overlap
+	if len(originalWords) < minWords || len(existingWords) < minWords {
+		return normalizeName(original) == normalizeName(existing)
+	}
+	return metric.Overlap(originalWords, existingWords) >= threshold
+}
+
+// normalizeName lower-cases a filename, drops the .html extension and
+// replaces separators with underscores
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, ".html"))
+	return nameSeparators.ReplaceAllString(name, "_")
+}
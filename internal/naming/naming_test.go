@@ -0,0 +1,205 @@
+package naming
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// gobyexampleNames are upstream example filenames of gobyexample.com
+var gobyexampleNames = []string{
+	"hello-world", "values", "variables", "constants", "for", "if-else",
+	"switch", "arrays", "slices", "maps", "functions", "multiple-return-values",
+	"variadic-functions", "closures", "recursion", "range-over-built-in-types",
+	"pointers", "strings-and-runes", "structs", "methods", "interfaces", "enums",
+	"struct-embedding", "generics", "range-over-iterators", "errors",
+	"custom-errors", "goroutines", "channels", "channel-buffering",
+	"channel-synchronization", "channel-directions", "select", "timeouts",
+	"non-blocking-channel-operations", "closing-channels", "range-over-channels",
+	"timers", "tickers", "worker-pools", "waitgroups", "rate-limiting",
+	"atomic-counters", "mutexes", "stateful-goroutines", "sorting",
+	"sorting-by-functions", "panic", "defer", "recover", "string-functions",
+	"string-formatting", "text-templates", "regular-expressions", "json", "xml",
+	"time", "epoch", "time-formatting-parsing", "random-numbers",
+	"number-parsing", "url-parsing", "sha256-hashes", "base64-encoding",
+	"reading-files", "writing-files", "line-filters", "file-paths",
+	"directories", "temporary-files-and-directories", "embed-directive",
+	"testing-and-benchmarking", "command-line-arguments", "command-line-flags",
+	"command-line-subcommands", "environment-variables", "logging",
+	"http-client", "http-server", "context", "spawning-processes",
+	"execing-processes", "signals", "exit",
+}
+
+// localHTMLNames returns the names as the local HTML files of a previous
+// build, e.g. "worker_pools.html"
+func localHTMLNames(names []string) []string {
+	local := make([]string, len(names))
+	for i, name := range names {
+		local[i] = strings.ReplaceAll(name, "-", "_") + ".html"
+	}
+	return local
+}
+
+func BenchmarkExtractWords(b *testing.B) {
+	for b.Loop() {
+		for _, name := range gobyexampleNames {
+			ExtractWords(name)
+		}
+	}
+}
+
+func BenchmarkWordOverlap(b *testing.B) {
+	original := ExtractWords("temporary-files-and-directories")
+	existing := ExtractWords("temporary_files_and_directories.html")
+	for b.Loop() {
+		WordOverlap(original, existing)
+	}
+}
+
+// BenchmarkMatchingLoop matches every upstream name against every local
+// file like fetchExample did before the index, stopping at the first match
+func BenchmarkMatchingLoop(b *testing.B) {
+	local := localHTMLNames(gobyexampleNames)
+	for b.Loop() {
+		for _, name := range gobyexampleNames {
+			for _, file := range local {
+				if Matches(name, file, 0.7, DefaultMinWords, MetricJaccard) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestWordOverlapNilAndEmpty(t *testing.T) {
+	tests := []struct {
+		name               string
+		original, existing []string
+	}{
+		{"nil/nil", nil, nil},
+		{"nil/non-nil", nil, []string{"hello"}},
+		{"non-nil/nil", []string{"hello"}, nil},
+		{"empty/empty", []string{}, []string{}},
+		{"empty/non-nil", []string{}, []string{"hello"}},
+		{"non-nil/empty", []string{"hello"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordOverlap(tt.original, tt.existing); got != 0.0 {
+				t.Errorf("WordOverlap(%v, %v) = %v, want 0", tt.original, tt.existing, got)
+			}
+		})
+	}
+}
+
+func TestWordOverlap(t *testing.T) {
+	tests := []struct {
+		original, existing []string
+		want               float64
+	}{
+		{[]string{"hello", "world"}, []string{"hello", "world"}, 1.0},
+		{[]string{"hello", "world", "example"}, []string{"hello", "world", "test"}, 0.5},
+		{[]string{"hello"}, []string{"world"}, 0.0},
+		{[]string{"map", "map"}, []string{"map"}, 1.0},
+	}
+	for _, tt := range tests {
+		if got := WordOverlap(tt.original, tt.existing); got != tt.want {
+			t.Errorf("WordOverlap(%v, %v) = %v, want %v", tt.original, tt.existing, got, tt.want)
+		}
+	}
+}
+
+// FuzzWordOverlap checks that WordOverlap never panics and stays within
+// [0, 1], both for raw word lists with empty and repeated words and for the
+// words of arbitrary filenames
+func FuzzWordOverlap(f *testing.F) {
+	f.Add("hello world", "hello world test")
+	f.Add("", "")
+	f.Add("map map", "map")
+	f.Add("hello-world", "hello_world.html")
+	f.Add("go-by-example", "closing-channels")
+	f.Fuzz(func(t *testing.T, original, existing string) {
+		for _, words := range [][2][]string{
+			{strings.Split(original, " "), strings.Split(existing, " ")},
+			{ExtractWords(original), ExtractWords(existing)},
+		} {
+			got := WordOverlap(words[0], words[1])
+			if got < 0 || got > 1 {
+				t.Errorf("WordOverlap(%q, %q) = %v, outside [0, 1]", words[0], words[1], got)
+			}
+		}
+	})
+}
+
+func TestMatchesShortNames(t *testing.T) {
+	tests := []struct {
+		original, existing string
+		minWords           int
+		want               bool
+	}{
+		// Single-word names sharing their only word overlap fully
+		{"maps", "maps.html", 1, true},
+		{"maps", "maps_and_slices.html", 1, false},
+		{"channels", "closing_channels.html", 1, false},
+
+		// Below minWords the names must be equal apart from case,
+		// extension and separators
+		{"maps", "maps.html", 2, true},
+		{"maps", "Maps.html", 2, true},
+		{"maps", "maps_and_slices.html", 2, false},
+		{"hello-world", "hello_world.html", 3, true},
+		{"hello-world", "hello_world_again.html", 3, false},
+
+		// Names of only common words have no words at all
+		{"go-by-example", "go_by_example.html", 1, true},
+		{"go-by-example", "example.html", 1, false},
+
+		// minWords below 1 behaves like 1
+		{"maps", "maps.html", 0, true},
+		{"go-by-example", "by.html", 0, false},
+
+		// Enough words: the overlap decides
+		{"closing-channels", "closing_channels.html", 2, true},
+		{"closing-channels", "closing_channels_explained.html", 2, false},
+	}
+	for _, tt := range tests {
+		got := Matches(tt.original, tt.existing, 0.7, tt.minWords, MetricJaccard)
+		if got != tt.want {
+			t.Errorf("Matches(%q, %q, minWords %d) = %v, want %v", tt.original, tt.existing, tt.minWords, got, tt.want)
+		}
+	}
+}
+
+func TestExtractWords(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     []string
+	}{
+		{"hello-world-example.html", []string{"hello", "world"}},
+		{"go_by_example_test", []string{"test"}},
+		{"go-by-example", nil},
+		{"Worker Pools: Intro", []string{"worker", "pools", "intro"}},
+
+		// Repeated words count once, in the order of their first occurrence
+		{"map-map-example", []string{"map"}},
+		{"Map_map-MAP", []string{"map"}},
+		{"sorting-by-sorting-functions", []string{"sorting", "functions"}},
+	}
+	for _, tt := range tests {
+		if got := ExtractWords(tt.filename); !slices.Equal(got, tt.want) {
+			t.Errorf("ExtractWords(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestWordSet(t *testing.T) {
+	set := WordSet("closing-channels-closing")
+	if len(set) != 2 {
+		t.Fatalf("WordSet has %d words, want 2: %v", len(set), set)
+	}
+	for _, word := range []string{"closing", "channels"} {
+		if _, ok := set[word]; !ok {
+			t.Errorf("WordSet lacks %q", word)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package pdfutil provides small helpers for inspecting PDF files.
+//
+// Page counts are needed several times per example during a build (for the
+// TOC, the bookmarks and the manifest). Parsing a PDF is comparatively
+// expensive, so PageCount memoizes its result per file version.
+//
+// Example usage:
+//
+//	pages, err := pdfutil.PageCount("files/hello-world.pdf")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+package pdfutil
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// fileVersion identifies a file's content by path, modification time and size
+type fileVersion struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[fileVersion]int)
+)
+
+// PageCount returns the number of pages of a PDF file
+//
+// Results are cached by path, modification time and size, so a file is only
+// parsed again after it was rewritten. Errors are returned as they are and
+// never cached; any fallback value is up to the caller.
+//
+// Parameters:
+//   - path: The PDF file to inspect
+//
+// Returns:
+//   - int: The number of pages
+//   - error: Any error that occurred while reading the file
+func PageCount(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not stat %s: %v", path, err)
+	}
+	key := fileVersion{path: path, modTime: info.ModTime(), size: info.Size()}
+
+	cacheMu.Lock()
+	count, ok := cache[key]
+	cacheMu.Unlock()
+	if ok {
+		return count, nil
+	}
+
+	count, err = api.PageCountFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	cacheMu.Lock()
+	cache[key] = count
+	cacheMu.Unlock()
+
+	return count, nil
+}
@@ -0,0 +1,154 @@
+// Package progress provides structured progress reporting for long-running
+// steps of the book generation.
+//
+// Instead of printing a log line for every processed example, callers report
+// progress through the Reporter interface. The default implementation renders
+// a single-line progress bar with counts and an ETA; library callers can use
+// Nop to silence progress entirely or JSON to emit machine-readable events.
+//
+// Example usage:
+//
+//	reporter := progress.NewBar(os.Stderr)
+//	reporter.Start(len(examples))
+//	for _, ex := range examples {
+//	    // ... process the example ...
+//	    reporter.Step(ex.Title)
+//	}
+//	reporter.Done()
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Reporter receives progress updates for a sequence of steps
+type Reporter interface {
+	Start(total int) // Start begins reporting for the given number of steps
+	Step(msg string) // Step marks one step as completed with a short description
+	Done()           // Done finishes reporting
+}
+
+// Nop is a Reporter that discards all progress updates
+type Nop struct{}
+
+func (Nop) Start(total int) {}
+func (Nop) Step(msg string) {}
+func (Nop) Done()           {}
+
+// barWidth is the number of characters used for the bar itself
+const barWidth = 30
+
+// Bar is a Reporter that renders a single-line progress bar
+//
+// The line is redrawn in place using a carriage return and shows the bar,
+// the completed and total step counts, the percentage, an ETA based on the
+// average step duration so far, and the message of the latest step.
+type Bar struct {
+	w       io.Writer
+	total   int
+	current int
+	started time.Time
+	lastLen int
+	now     func() time.Time
+}
+
+// NewBar returns a progress bar Reporter writing to w
+func NewBar(w io.Writer) *Bar {
+	return &Bar{w: w, now: time.Now}
+}
+
+// Start resets the bar for the given number of steps and draws it
+func (b *Bar) Start(total int) {
+	b.total = total
+	b.current = 0
+	b.started = b.now()
+	b.lastLen = 0
+	b.render("")
+}
+
+// Step advances the bar by one step and redraws it
+func (b *Bar) Step(msg string) {
+	b.current++
+	b.render(msg)
+}
+
+// Done finishes the bar line so subsequent output starts on a new line
+func (b *Bar) Done() {
+	fmt.Fprintln(b.w)
+}
+
+// render draws the current state of the bar, overwriting the previous line
+func (b *Bar) render(msg string) {
+	filled := 0
+	percent := 100
+	if b.total > 0 {
+		filled = b.current * barWidth / b.total
+		percent = b.current * 100 / b.total
+	}
+
+	eta := "--"
+	if b.current > 0 && b.current < b.total {
+		perStep := b.now().Sub(b.started) / time.Duration(b.current)
+		eta = (perStep * time.Duration(b.total-b.current)).Round(time.Second).String()
+	} else if b.current >= b.total {
+		eta = "0s"
+	}
+
+	line := fmt.Sprintf("[%s%s] %d/%d (%d%%) ETA %s %s",
+		strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled),
+		b.current, b.total, percent, eta, msg)
+
+	// Pad with spaces to clear leftovers from a longer previous line
+	padding := ""
+	if len(line) < b.lastLen {
+		padding = strings.Repeat(" ", b.lastLen-len(line))
+	}
+	b.lastLen = len(line)
+
+	fmt.Fprintf(b.w, "\r%s%s", line, padding)
+}
+
+// JSON is a Reporter that writes one JSON object per event
+//
+// Each line has an "event" field ("start", "step" or "done") plus the
+// relevant counts, which makes the output easy to consume from other tools.
+type JSON struct {
+	enc     *json.Encoder
+	total   int
+	current int
+}
+
+// NewJSON returns a JSON Reporter writing to w
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{enc: json.NewEncoder(w)}
+}
+
+// jsonEvent is the structure written for every progress event
+type jsonEvent struct {
+	Event   string `json:"event"`
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// Start records the total number of steps and emits a start event
+func (j *JSON) Start(total int) {
+	j.total = total
+	j.current = 0
+	j.enc.Encode(jsonEvent{Event: "start", Total: total})
+}
+
+// Step emits a step event for the completed step
+func (j *JSON) Step(msg string) {
+	j.current++
+	j.enc.Encode(jsonEvent{Event: "step", Current: j.current, Total: j.total, Message: msg})
+}
+
+// Done emits a done event
+func (j *JSON) Done() {
+	j.enc.Encode(jsonEvent{Event: "done", Current: j.current, Total: j.total})
+}
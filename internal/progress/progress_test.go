@@ -0,0 +1,96 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewJSON(&buf)
+	reporter.Start(2)
+	reporter.Step("Hello World")
+	reporter.Step("Values")
+	reporter.Done()
+
+	want := []jsonEvent{
+		{Event: "start", Total: 2},
+		{Event: "step", Current: 1, Total: 2, Message: "Hello World"},
+		{Event: "step", Current: 2, Total: 2, Message: "Values"},
+		{Event: "done", Current: 2, Total: 2},
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	var got []jsonEvent
+	for _, line := range lines {
+		var event jsonEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not a JSON object: %v", line, err)
+		}
+		got = append(got, event)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+
+	// Steps without a message leave the field out
+	if strings.Contains(lines[0], "message") {
+		t.Errorf("start event %s has a message", lines[0])
+	}
+}
+
+func TestJSONRestart(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewJSON(&buf)
+	reporter.Start(3)
+	reporter.Step("a")
+	buf.Reset()
+
+	reporter.Start(1)
+	reporter.Step("b")
+	if want := `{"event":"step","current":1,"total":1,"message":"b"}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("after a restart got %q, want the counts to start over with %s", buf.String(), want)
+	}
+}
+
+func TestBar(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	bar := NewBar(&buf)
+	bar.now = func() time.Time { return now }
+
+	bar.Start(4)
+	now = now.Add(10 * time.Second)
+	bar.Step("Hello World")
+	now = now.Add(10 * time.Second)
+	bar.Step("Go")
+	bar.Done()
+
+	frames := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\r")[1:]
+	want := []string{
+		"[------------------------------] 0/4 (0%) ETA -- ",
+		"[#######-----------------------] 1/4 (25%) ETA 30s Hello World",
+		"[###############---------------] 2/4 (50%) ETA 20s Go",
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("got %d frames, want %d: %q", len(frames), len(want), frames)
+	}
+	for i := range want {
+		if strings.TrimRight(frames[i], " ") != strings.TrimRight(want[i], " ") {
+			t.Errorf("frame %d = %q, want %q", i, frames[i], want[i])
+		}
+	}
+	// The shorter last frame is padded to clear the longer one before it
+	if len(frames[2]) != len(frames[1]) {
+		t.Errorf("frame 2 has %d characters, want %d to overwrite frame 1", len(frames[2]), len(frames[1]))
+	}
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Error("Done does not end the line")
+	}
+}
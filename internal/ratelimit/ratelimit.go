@@ -0,0 +1,126 @@
+// Package ratelimit provides a token-bucket rate limiter shared by
+// concurrent workers.
+//
+// Raising the concurrency of a worker pool must not raise the rate of
+// requests against GitHub or the browser. A Limiter is shared by all
+// workers: every worker calls Wait before its request, and the limiter
+// spaces the requests so the configured rate holds across all of them.
+//
+// Example usage:
+//
+//	limiter := ratelimit.New(10, 1) // 10 requests per second, no bursts
+//	for _, url := range urls {
+//	    go func() {
+//	        limiter.Wait()
+//	        http.Get(url)
+//	    }()
+//	}
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token-bucket rate limiter that is safe for concurrent use
+//
+// The bucket holds up to burst tokens and earns one token per interval.
+// Every Wait takes a token, waiting until one is available. A nil Limiter
+// or one with a zero interval never waits.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration // Time to earn one token
+	burst    float64       // Bucket capacity
+	tokens   float64       // Available tokens; negative when callers are waiting
+	last     time.Time     // Time tokens was last updated
+}
+
+// New returns a limiter allowing perSecond requests per second on average
+// and bursts of up to burst requests
+//
+// Parameters:
+//   - perSecond: The sustained rate; zero or less disables limiting
+//   - burst: The number of requests that may run back to back; values below 1 are treated as 1
+//
+// Returns:
+//   - *Limiter: The limiter, starting with a full bucket
+func New(perSecond float64, burst int) *Limiter {
+	if perSecond <= 0 {
+		return &Limiter{}
+	}
+	return newLimiter(time.Duration(float64(time.Second)/perSecond), burst)
+}
+
+// Every returns a limiter that spaces requests at least interval apart
+//
+// This is New with a rate of one request per interval and no bursts; an
+// interval of zero or less disables limiting.
+func Every(interval time.Duration) *Limiter {
+	if interval <= 0 {
+		return &Limiter{}
+	}
+	return newLimiter(interval, 1)
+}
+
+// newLimiter returns a limiter with a full bucket
+func newLimiter(interval time.Duration, burst int) *Limiter {
+	b := float64(max(burst, 1))
+	return &Limiter{interval: interval, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until the caller may issue its request
+//
+// Callers are served in the order they call Wait: each takes its token
+// right away, even if that leaves the bucket in debt, and then sleeps until
+// the debt is paid off.
+func (l *Limiter) Wait() {
+	time.Sleep(l.reserve())
+}
+
+// WaitContext works like Wait but gives up when ctx is done
+//
+// A caller that gives up returns its token, so the callers after it do not
+// wait for a request that is never made.
+//
+// Returns:
+//   - error: ctx.Err() if ctx was done before the request could be issued, nil otherwise
+func (l *Limiter) WaitContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait for it
+func (l *Limiter) reserve() time.Duration {
+	if l == nil || l.interval <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+	l.tokens--
+	if l.tokens < 0 {
+		return time.Duration(-l.tokens * float64(l.interval))
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// elapsed returns how long f took
+func elapsed(f func()) time.Duration {
+	start := time.Now()
+	f()
+	return time.Since(start)
+}
+
+func TestEverySpacesRequests(t *testing.T) {
+	l := Every(20 * time.Millisecond)
+	d := elapsed(func() {
+		for range 5 {
+			l.Wait()
+		}
+	})
+	// The first request goes right away, the other four wait an interval each
+	if d < 80*time.Millisecond {
+		t.Errorf("5 requests took %v, want at least 80ms", d)
+	}
+	if d > 500*time.Millisecond {
+		t.Errorf("5 requests took %v, far more than the expected 80ms", d)
+	}
+}
+
+func TestEverySharedByWorkers(t *testing.T) {
+	l := Every(20 * time.Millisecond)
+	d := elapsed(func() {
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.Wait()
+			}()
+		}
+		wg.Wait()
+	})
+	if d < 60*time.Millisecond {
+		t.Errorf("4 concurrent requests took %v, want at least 60ms", d)
+	}
+}
+
+func TestNewAllowsBursts(t *testing.T) {
+	l := New(20, 3) // One token every 50ms
+	if d := elapsed(func() { l.Wait(); l.Wait(); l.Wait() }); d > 25*time.Millisecond {
+		t.Errorf("a burst of 3 took %v, want no waiting", d)
+	}
+	if d := elapsed(l.Wait); d < 40*time.Millisecond {
+		t.Errorf("the request after the burst waited %v, want about 50ms", d)
+	}
+}
+
+func TestDisabledNeverWaits(t *testing.T) {
+	for name, l := range map[string]*Limiter{
+		"nil":          nil,
+		"Every(0)":     Every(0),
+		"New(0, 1)":    New(0, 1),
+		"Every(-1s)":   Every(-time.Second),
+		"New(-5, 10)":  New(-5, 10),
+		"zero Limiter": {},
+	} {
+		d := elapsed(func() {
+			for range 100 {
+				l.Wait()
+				if err := l.WaitContext(context.Background()); err != nil {
+					t.Errorf("%s: WaitContext: %v", name, err)
+				}
+			}
+		})
+		if d > 25*time.Millisecond {
+			t.Errorf("%s: 200 requests took %v, want no waiting", name, d)
+		}
+	}
+}
+
+func TestWaitContextCanceled(t *testing.T) {
+	l := Every(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A canceled context neither waits nor takes the token
+	if err := l.WaitContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if err := l.WaitContext(context.Background()); err != nil {
+		t.Errorf("the token was taken by the canceled call: %v", err)
+	}
+}
+
+func TestWaitContextDeadline(t *testing.T) {
+	l := Every(100 * time.Millisecond)
+	l.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var err error
+	if d := elapsed(func() { err = l.WaitContext(ctx) }); d > 60*time.Millisecond {
+		t.Errorf("WaitContext returned after %v, want right after the 10ms deadline", d)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	// The abandoned token is returned, so the next caller only waits for
+	// the rest of the first interval instead of two
+	if d := elapsed(l.Wait); d > 150*time.Millisecond {
+		t.Errorf("the next request waited %v, want at most one interval", d)
+	}
+}
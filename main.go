@@ -1,208 +1,494 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"go-by-example-book/internal/build"
 	"go-by-example-book/internal/github"
 	"go-by-example-book/internal/htmlpdf"
-	"log"
+	"go-by-example-book/internal/logging"
+	"go-by-example-book/internal/naming"
+	"go-by-example-book/internal/progress"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/go-rod/rod"
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
-// prepOutputDir prepares the output directory for the PDF generation process
+// prepLogger creates the leveled logger for the whole run
 //
-// This function creates the output directory if it doesn't exist and returns
-// the path to be used throughout the PDF generation process.
+// The verbosity flags map to log levels: quiet only shows warnings and
+// errors, verbose adds debug output, and the default shows informational
+// messages. When logFile is set, output is appended to that file instead of
+// being written to console.
 //
 // Returns:
-//   - string: The path to the prepared output directory
-func prepOutputDir() string {
-	outputDir := "files"
-	os.MkdirAll(outputDir, 0755)
-	return outputDir
-}
+//   - *slog.Logger: The configured logger
+//   - func(): A cleanup function that closes the log file, if any
+//   - error: Any error that occurred while opening the log file
+func prepLogger(quiet, verbose bool, logFile string, console io.Writer) (*slog.Logger, func(), error) {
+	level := slog.LevelInfo
+	if quiet {
+		level = slog.LevelWarn
+	}
+	if verbose {
+		level = slog.LevelDebug
+	}
 
-// prepHeadlessBrowser initializes and returns a Rod browser instance for PDF generation
-//
-// This function creates a new headless browser instance that will be used
-// for converting HTML files to PDF format. The browser is configured with
-// default settings suitable for PDF generation.
-//
-// Returns:
-//   - *rod.Browser: A configured browser instance ready for PDF generation
-func prepHeadlessBrowser() *rod.Browser {
-	browser := rod.New().MustConnect()
-	return browser
+	w := console
+	cleanup := func() {}
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %v", err)
+		}
+		w = f
+		cleanup = func() { f.Close() }
+	}
+
+	return logging.New(w, level), cleanup, nil
 }
 
-func main() {
-	fmt.Println("[INFO] Starting Go by Example PDF generator with Rod + pdfcpu...")
-	outputDir := prepOutputDir()
+// exitPartial is the exit code of a build that produced the book but had to
+// leave out some examples
+const exitPartial = 3
+
+// exitInterrupted is the exit code after Ctrl-C, following the shell
+// convention of 128 + SIGINT
+const exitInterrupted = 130
+
+// cancelOnInterrupt returns a context that is canceled on the first SIGINT so
+// the build can finish the current example and write a partial book; a second
+// SIGINT exits immediately
+func cancelOnInterrupt(logger *slog.Logger) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+
+	go func() {
+		if _, ok := <-sigs; !ok {
+			return
+		}
+		logger.Warn("Interrupted, finishing the current example; press Ctrl-C again to exit immediately")
+		cancel()
+		if _, ok := <-sigs; ok {
+			os.Exit(exitInterrupted)
+		}
+	}()
 
-	examples, err := github.GetGitHubFiles(outputDir)
-	if err != nil {
-		log.Fatalf("[ERROR] Failed to get examples: %v", err)
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(sigs)
+		cancel()
 	}
-	fmt.Printf("[INFO] Found %d examples\n", len(examples))
+}
 
-	browser := prepHeadlessBrowser()
-	defer browser.MustClose()
+// failureHint returns remediation advice for well-known build errors, or an
+// empty string if there is none
+func failureHint(err error) string {
+	switch {
+	case errors.Is(err, github.ErrListingUnavailable):
+		return "Could not read the example listing. Check your network connection or proxy settings (or the -repo-dir path) and try again."
+	case errors.Is(err, github.ErrNoExamplesFound):
+		return "GitHub's page format may have changed. Please check for an update of this tool or report an issue."
+	default:
+		return ""
+	}
+}
 
-	// Generate individual PDFs first (without TOC)
-	var pdfPaths []string
-	var examplePageCounts []int // Track page count for each example
+// sourceDateEpochEnv is the environment variable reproducible build tools
+// use to pass a fixed timestamp, in seconds since the Unix epoch
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
 
-	// Generate individual example PDFs
-	for i, ex := range examples {
-		fileStatus := htmlpdf.ReceiveOutputFileStatus(outputDir, ex.File)
+// parseBuildDate returns the date recorded in the final PDF
+//
+// The -date flag takes a date like 2024-01-31 or an RFC 3339 timestamp. If
+// it is empty, $SOURCE_DATE_EPOCH is used; if that is unset too, the zero
+// time is returned, which keeps the time of the build.
+func parseBuildDate(value string) (time.Time, error) {
+	if value != "" {
+		return parseDate("-date", value)
+	}
 
-		// If both files exist, skip this example
-		if fileStatus.HTMLExists && fileStatus.PDFExists {
-			result := htmlpdf.UpdatePageCountForDownloadedExamples(ex, fileStatus, pdfPaths, examplePageCounts)
-			pdfPaths = result.PDFPaths
-			examplePageCounts = result.ExamplePageCounts
-			continue
+	if epoch := os.Getenv(sourceDateEpochEnv); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("$%s must be a number of seconds", sourceDateEpochEnv)
 		}
-
-		// Save original HTML content (only if HTML doesn't exist)
-		if !fileStatus.HTMLExists {
-			err = htmlpdf.CreateHTMLFile(ex.Content, fileStatus.HTMLPath)
-			if err != nil {
-				log.Printf("[ERROR] Could not create HTML for %s: %v", ex.Title, err)
-				continue
-			}
+		t := time.Unix(seconds, 0).UTC()
+		if err := checkDateYear("$"+sourceDateEpochEnv, t); err != nil {
+			return time.Time{}, err
 		}
+		return t, nil
+	}
+	return time.Time{}, nil
+}
 
-		// Convert to PDF (only if PDF doesn't exist)
-		if !fileStatus.PDFExists {
-			err = htmlpdf.HTMLToPDF(browser, fileStatus.HTMLPath, fileStatus.PDFPath)
-			if err != nil {
-				log.Printf("[ERROR] Could not create PDF for %s: %v", ex.Title, err)
-				continue
-			}
-			fmt.Printf("[PDF CREATED] %s.pdf (Example %d)\n", ex.File, i+1)
-		} else {
-			fmt.Printf("[PDF EXISTS] %s.pdf (Example %d)\n", ex.File, i+1)
+// parseDate parses the value of a date flag, a date like 2024-01-31 or an
+// RFC 3339 timestamp
+//
+// The year must have four digits, the years a PDF date can hold.
+func parseDate(flagName, value string) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, fmt.Errorf("%s must be a date like 2024-01-31 or an RFC 3339 timestamp", flagName)
 		}
+	}
+	if err := checkDateYear(flagName, t); err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
 
-		pdfPaths = append(pdfPaths, fileStatus.PDFPath)
+// checkDateYear returns an error if the year of t in UTC is outside the
+// years htmlpdf.StampDate can record
+func checkDateYear(name string, t time.Time) error {
+	if year := t.UTC().Year(); year < htmlpdf.MinDateYear || year > htmlpdf.MaxDateYear {
+		return fmt.Errorf("%s must be a date between the years %d and %d", name, htmlpdf.MinDateYear, htmlpdf.MaxDateYear)
+	}
+	return nil
+}
 
-		// Get page count of the generated PDF
-		pageCount, err := api.PageCountFile(fileStatus.PDFPath)
-		if err != nil {
-			log.Printf("[WARNING] Could not get page count for %s: %v", ex.Title, err)
-			pageCount = 1 // fallback assumption
+// configFileArg returns the value of the -config flag, or an empty string
+//
+// The flag is looked up before the command line is parsed, since the file
+// provides the defaults of all other flags.
+func configFileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
 		}
-		examplePageCounts = append(examplePageCounts, pageCount)
-		fmt.Printf("[PAGE COUNT] %s: %d pages\n", ex.Title, pageCount)
-
-		// Small delay to be nice to the browser
-		time.Sleep(100 * time.Millisecond)
 	}
+	return ""
+}
 
-	// Merge all example PDFs into one (without TOC)
-	mergedExamplesPdf := filepath.Join(outputDir, "merged_examples.pdf")
-
-	// Use pdfcpu to merge PDFs
-	conf := model.NewDefaultConfiguration()
+func main() {
+	os.Exit(run())
+}
 
-	err = api.MergeCreateFile(pdfPaths, mergedExamplesPdf, false, conf)
-	if err != nil {
-		log.Fatalf("[ERROR] Could not merge example PDFs: %v", err)
+// run parses the command line, executes the build and returns the exit code
+//
+// Keeping this separate from main lets deferred cleanup (like closing the
+// log file) run before the process exits.
+func run() int {
+	// The config file is loaded before the flags are defined, so its values
+	// become the flag defaults and explicit flags override them
+	cfg := build.DefaultConfig()
+	configFile := configFileArg(os.Args[1:])
+	if configFile != "" {
+		if err := build.LoadConfigFile(configFile, &cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			return 2
+		}
 	}
-	fmt.Printf("[EXAMPLES MERGED] %s\n", mergedExamplesPdf)
-
-	// Create intro page with TOC and instructions
-	fmt.Println("[INFO] Creating intro page...")
-
-	// First, create a temporary TOC with placeholder page numbers
-	tempIntroHTML := htmlpdf.CreateBaseHtmlTemplate()
-
-	// Add placeholder TOC entries
-	tempIntroHTML += htmlpdf.AddPageInfoToTOC(examples, 1, nil)
-
-	tempIntroHTML += htmlpdf.CloseTOCList()
-
-	tempIntroHtmlPath := filepath.Join(outputDir, "temp_intro.html")
-	err = htmlpdf.WriteHTMLAndPDFExp(htmlpdf.HTMLToPDFParams{
-		HTMLContent: tempIntroHTML,
-		HTMLPath:    tempIntroHtmlPath,
-		PDFPath:     filepath.Join(outputDir, "temp_intro.pdf"),
-		Browser:     browser,
-		Description: "temp intro",
+	flag.String("config", configFile, "JSON file with default options, keyed by flag name; flags given on the command line override it")
+	flag.StringVar(&cfg.OutputDir, "out", cfg.OutputDir, "directory for per-example HTML files and assets")
+	flag.StringVar(&cfg.PDFDir, "pdf-dir", cfg.PDFDir, "directory for per-example PDFs (default: the -out directory)")
+	flag.StringVar(&cfg.FinalPDF, "o", cfg.FinalPDF, "path of the combined PDF")
+	flag.StringVar(&cfg.RepoDir, "repo-dir", cfg.RepoDir, "build offline from a local clone of the gobyexample repository")
+	flag.StringVar(&cfg.RawBaseURL, "raw-base-url", cfg.RawBaseURL, "base URL the example HTML and assets are downloaded from, e.g. a jsDelivr mirror of the gobyexample public/ directory")
+	flag.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "number of examples fetched in parallel")
+	flag.Float64Var(&cfg.Threshold, "threshold", cfg.Threshold, "minimum word overlap (0.0-1.0) for reusing an existing local HTML file")
+	flag.IntVar(&cfg.MinWords, "min-words", cfg.MinWords, "minimum words of both filenames for -threshold matching; shorter names must match exactly")
+	matchMetric := flag.String("match-metric", string(cfg.MatchMetric), "overlap measure for -threshold: jaccard (shared words of all words) or coefficient (shared words of the shorter name, tolerates extra words)")
+	flag.DurationVar(&cfg.RequestDelay, "request-delay", cfg.RequestDelay, "minimum time between two download requests, shared by all workers (0 disables)")
+	flag.StringVar(&cfg.UserAgent, "user-agent", cfg.UserAgent, "User-Agent header sent with every download request")
+	flag.IntVar(&cfg.MinContentSize, "min-size", cfg.MinContentSize, "skip examples whose HTML is smaller than this many bytes (0 disables)")
+	flag.IntVar(&cfg.Limit, "limit", cfg.Limit, "only build the first N examples, for quick test builds (0 builds all)")
+	flag.StringVar(&cfg.Include, "include", cfg.Include, "only build examples whose filename matches this regular expression")
+	flag.StringVar(&cfg.Exclude, "exclude", cfg.Exclude, "skip examples whose filename matches this regular expression (wins over -include)")
+	flag.StringVar(&cfg.BrowserBinPath, "browser", cfg.BrowserBinPath, "path to a Chromium/Chrome executable (default $"+build.BrowserPathEnv+", otherwise auto-detect or download)")
+	flag.BoolVar(&cfg.NoSandbox, "no-sandbox", cfg.NoSandbox, "start Chromium without its sandbox; required when running as root, e.g. in Docker or CI")
+	flag.BoolVar(&cfg.InlineAssets, "inline-assets", cfg.InlineAssets, "inline site.css and images so each example HTML is self-contained")
+	flag.BoolVar(&cfg.KeepInteractive, "keep-buttons", cfg.KeepInteractive, "keep the interactive run/copy buttons in the rendered pages")
+	flag.BoolVar(&cfg.SourceFooter, "source-url", cfg.SourceFooter, "add a footer with the example's gobyexample.com URL to every example, for attribution")
+	flag.BoolVar(&cfg.NumberExamples, "number-examples", cfg.NumberExamples, "add an \"Example N\" heading to every example, numbered like the bookmarks")
+	flag.BoolVar(&cfg.ValidateHTML, "validate-html", cfg.ValidateHTML, "fail examples whose HTML has no <body> or is not HTML, e.g. a saved JSON error, instead of rendering a blank page")
+	flag.BoolVar(&cfg.NormalizeHTML, "normalize-html", cfg.NormalizeHTML, "rewrite each example's HTML into well-formed markup before rendering for stable page counts")
+	flag.StringVar(&cfg.CombinedHTML, "html", cfg.CombinedHTML, "write all examples into this single HTML file instead of a PDF (no browser needed)")
+	flag.BoolVar(&cfg.HTMLPageBreaks, "html-page-breaks", cfg.HTMLPageBreaks, "start every example of the -html document on a new page when printed")
+	flag.StringVar(&cfg.CodeDir, "code", cfg.CodeDir, "write the Go source of every example, without the prose, as .go files into this directory instead of a PDF (no browser needed)")
+	flag.StringVar(&cfg.Watermark.Text, "watermark", cfg.Watermark.Text, "stamp this text, e.g. DRAFT, diagonally on every page")
+	flag.Float64Var(&cfg.Watermark.Opacity, "watermark-opacity", cfg.Watermark.Opacity, "opacity of the watermark (0.0-1.0)")
+	flag.Float64Var(&cfg.Watermark.Rotation, "watermark-rotation", cfg.Watermark.Rotation, "rotation of the watermark in degrees")
+	flag.StringVar(&cfg.Watermark.Color, "watermark-color", cfg.Watermark.Color, "color of the watermark as #RRGGBB")
+	flag.BoolVar(&cfg.Force, "force", cfg.Force, "re-download and re-render every example, ignoring existing HTML and PDF files")
+	flag.BoolVar(&cfg.RefreshAssets, "refresh-assets", cfg.RefreshAssets, "re-download site.css and the images even if they exist (also done by -force)")
+	flag.BoolVar(&cfg.IntroOnly, "intro-only", cfg.IntroOnly, "rebuild the intro, TOC and bookmarks from the example PDFs of the previous build without fetching or rendering")
+	flag.BoolVar(&cfg.ExamplesOnly, "examples-only", cfg.ExamplesOnly, "write only the merged examples, without intro, TOC and bookmarks")
+	flag.BoolVar(&cfg.NoMerge, "no-merge", cfg.NoMerge, "only render the per-example PDFs, without writing a book")
+	flag.BoolVar(&cfg.Index, "index", cfg.Index, "add an alphabetical index of the examples with their page numbers after the last example")
+	flag.BoolVar(&cfg.GroupByCategory, "group", cfg.GroupByCategory, "order the book by category with TOC sections and nested bookmarks")
+	flag.BoolVar(&cfg.SplitByCategory, "split", cfg.SplitByCategory, "write one booklet per category (e.g. book-concurrency.pdf) instead of a single PDF")
+	flag.BoolVar(&cfg.Duplex, "duplex", cfg.Duplex, "insert blank pages so every example starts on a right-hand page for double-sided printing")
+	flag.BoolVar(&cfg.Optimize, "optimize", cfg.Optimize, "deduplicate fonts and images in the final PDF to reduce its size")
+	flag.StringVar(&cfg.Encryption.UserPassword, "user-password", cfg.Encryption.UserPassword, "password required to open the final PDF (enables encryption)")
+	flag.StringVar(&cfg.Encryption.OwnerPassword, "owner-password", cfg.Encryption.OwnerPassword, "password required to change the PDF's permissions (enables encryption)")
+	flag.BoolVar(&cfg.Encryption.AllowPrint, "allow-print", cfg.Encryption.AllowPrint, "allow printing the encrypted PDF")
+	flag.BoolVar(&cfg.Encryption.AllowCopy, "allow-copy", cfg.Encryption.AllowCopy, "allow copying text from the encrypted PDF")
+	fontsFromFlags := false
+	flag.Func("font", "font file (TTF/OTF/WOFF) for characters the site's fonts lack, e.g. CJK; may be repeated (replaces the fonts of -config)", func(path string) error {
+		if !fontsFromFlags {
+			cfg.FontFiles = nil
+			fontsFromFlags = true
+		}
+		cfg.FontFiles = append(cfg.FontFiles, path)
+		return nil
 	})
+	markdownFromFlags := false
+	flag.Func("markdown", "your own lesson as a Markdown file, or a directory of .md files, added to the book like an example; may be repeated (replaces the files of -config)", func(path string) error {
+		if !markdownFromFlags {
+			cfg.MarkdownFiles = nil
+			markdownFromFlags = true
+		}
+		cfg.MarkdownFiles = append(cfg.MarkdownFiles, path)
+		return nil
+	})
+	markdownOrder := flag.String("markdown-order", string(cfg.MarkdownOrder), "where the -markdown lessons go: end, start or sorted (among the examples by title)")
+	appendicesFromFlags := false
+	flag.Func("appendix", "PDF, e.g. a reference card, to append to the book with its own bookmark; may be repeated (replaces the appendices of -config)", func(path string) error {
+		if !appendicesFromFlags {
+			cfg.Appendices = nil
+			appendicesFromFlags = true
+		}
+		cfg.Appendices = append(cfg.Appendices, path)
+		return nil
+	})
+	flag.Float64Var(&cfg.Scale, "scale", cfg.Scale, "print scale of the example pages (0.1-2.0), e.g. 0.9 to fit wide code")
+	flag.Float64Var(&cfg.DeviceScale, "device-scale", cfg.DeviceScale, "device pixel ratio of the example pages (1-4), e.g. 2 for sharper images in print (0 keeps the browser's)")
+	flag.StringVar(&cfg.WaitSelector, "wait-for", cfg.WaitSelector, "CSS selector, e.g. 'td.code pre.chroma', that must be present before a page is printed")
+	flag.DurationVar(&cfg.WaitTimeout, "wait-timeout", cfg.WaitTimeout, "how long to wait for the -wait-for selector before the example fails")
+	flag.DurationVar(&cfg.RenderDelay, "render-delay", cfg.RenderDelay, "pause after every rendered example to ease the load on the browser (0 disables)")
+	flag.Float64Var(&cfg.CostPerPage, "cost-per-page", cfg.CostPerPage, "price of printing one page; logs the estimated printing cost of the book (0 disables)")
+	flag.BoolVar(&cfg.KeepTemp, "keep-temp", cfg.KeepTemp, "keep the temporary directory with the intermediate intro and merged files for debugging")
+	since := flag.String("since", "", "only include examples changed since this date, e.g. 2024-01-31, for a supplement of what is new; examples of unknown date are included")
+	date := flag.String("date", "", "creation date recorded in the final PDF, e.g. 2024-01-31, for reproducible builds (default $"+sourceDateEpochEnv+", otherwise the current time)")
+	validation := flag.String("validate", string(cfg.Validation), "validation of the final PDF: off, warn or fail")
+	layout := flag.String("layout", string(cfg.Layout), "page layout of the examples: single or two-column (explanation and code side by side)")
+	printTheme := flag.Bool("print-theme", false, "use a print-friendly, high-contrast code theme")
+	themeFile := flag.String("theme-css", "", "CSS file applied after site.css to override the page styling")
+	prefaceFile := flag.String("preface", "", "HTML file with a preface placed between the introduction and the TOC")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors")
+	verbose := flag.Bool("verbose", false, "log debug output")
+	jsonResult := flag.Bool("json", false, "print the build result as JSON to stdout; logs go to stderr and only show warnings and errors")
+	logFile := flag.String("log-file", "", "append log output to this file instead of stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Generates a PDF e-book from the Go by Example website.")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nOptions:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	buildDate, err := parseBuildDate(*date)
 	if err != nil {
-		log.Fatalf("[ERROR] Could not create temp intro: %v", err)
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		return 2
 	}
-
-	// Get the actual page count of the intro PDF
-	introPageCount, err := api.PageCountFile(filepath.Join(outputDir, "temp_intro.pdf"))
+	cfg.Date = buildDate
+	if *since != "" {
+		cfg.Since, err = parseDate("-since", *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			return 2
+		}
+	}
+	if cfg.Threshold < 0 || cfg.Threshold > 1 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -threshold must be between 0.0 and 1.0")
+		return 2
+	}
+	cfg.Validation = build.ValidationMode(*validation)
+	switch cfg.Validation {
+	case build.ValidationOff, build.ValidationWarn, build.ValidationFail:
+	default:
+		fmt.Fprintln(os.Stderr, "[ERROR] -validate must be off, warn or fail")
+		return 2
+	}
+	cfg.MarkdownOrder = build.MarkdownOrder(*markdownOrder)
+	switch cfg.MarkdownOrder {
+	case build.MarkdownEnd, build.MarkdownStart, build.MarkdownSorted:
+	default:
+		fmt.Fprintln(os.Stderr, "[ERROR] -markdown-order must be end, start or sorted")
+		return 2
+	}
+	if cfg.Watermark.Opacity < 0 || cfg.Watermark.Opacity > 1 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -watermark-opacity must be between 0.0 and 1.0")
+		return 2
+	}
+	if cfg.Scale < htmlpdf.MinScale || cfg.Scale > htmlpdf.MaxScale {
+		fmt.Fprintf(os.Stderr, "[ERROR] -scale must be between %g and %g\n", htmlpdf.MinScale, htmlpdf.MaxScale)
+		return 2
+	}
+	if cfg.DeviceScale != 0 && (cfg.DeviceScale < 1 || cfg.DeviceScale > htmlpdf.MaxDeviceScale) {
+		fmt.Fprintf(os.Stderr, "[ERROR] -device-scale must be 0 or between 1 and %g\n", htmlpdf.MaxDeviceScale)
+		return 2
+	}
+	if cfg.IntroOnly && cfg.Force {
+		fmt.Fprintln(os.Stderr, "[ERROR] -intro-only cannot be combined with -force")
+		return 2
+	}
+	if cfg.IntroOnly && !cfg.Since.IsZero() {
+		fmt.Fprintln(os.Stderr, "[ERROR] -intro-only cannot be combined with -since")
+		return 2
+	}
+	if cfg.NoMerge && (cfg.IntroOnly || cfg.ExamplesOnly || cfg.SplitByCategory || cfg.CombinedHTML != "" || cfg.CodeDir != "") {
+		fmt.Fprintln(os.Stderr, "[ERROR] -no-merge cannot be combined with -intro-only, -examples-only, -split, -html or -code")
+		return 2
+	}
+	if cfg.Index && (cfg.ExamplesOnly || cfg.NoMerge || cfg.CombinedHTML != "" || cfg.CodeDir != "") {
+		fmt.Fprintln(os.Stderr, "[ERROR] -index cannot be combined with -examples-only, -no-merge, -html or -code")
+		return 2
+	}
+	if cfg.IntroOnly && cfg.CombinedHTML != "" {
+		fmt.Fprintln(os.Stderr, "[ERROR] -intro-only cannot be combined with -html")
+		return 2
+	}
+	if cfg.CodeDir != "" && (cfg.IntroOnly || cfg.CombinedHTML != "") {
+		fmt.Fprintln(os.Stderr, "[ERROR] -code cannot be combined with -intro-only or -html")
+		return 2
+	}
+	if cfg.MinWords < 1 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -min-words must be at least 1")
+		return 2
+	}
+	if u, err := url.Parse(cfg.RawBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fmt.Fprintln(os.Stderr, "[ERROR] -raw-base-url must be an http or https URL")
+		return 2
+	}
+	if cfg.CostPerPage < 0 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -cost-per-page must not be negative")
+		return 2
+	}
+	if cfg.WaitTimeout <= 0 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -wait-timeout must be positive")
+		return 2
+	}
+	if cfg.RequestDelay < 0 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -request-delay must not be negative")
+		return 2
+	}
+	if cfg.RenderDelay < 0 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -render-delay must not be negative")
+		return 2
+	}
+	if cfg.Limit < 0 {
+		fmt.Fprintln(os.Stderr, "[ERROR] -limit must not be negative")
+		return 2
+	}
+	parsedLayout, err := htmlpdf.ParseLayout(*layout)
 	if err != nil {
-		log.Printf("[WARNING] Could not get intro page count: %v", err)
-		introPageCount = 2 // fallback assumption
+		fmt.Fprintf(os.Stderr, "[ERROR] -layout: %v\n", err)
+		return 2
 	}
-	fmt.Printf("[INTRO PAGE COUNT] %d pages\n", introPageCount)
-
-	// Now create the final intro HTML with correct page numbers
-	introHTML := htmlpdf.CreateBaseHtmlTemplate()
-
-	// Add TOC entries with correct page numbers
-	introHTML += htmlpdf.AddPageInfoToTOC(examples, introPageCount+1, examplePageCounts)
-
-	introHTML += htmlpdf.CloseTOCList()
-
-	introHtmlPath := filepath.Join(outputDir, "intro.html")
-	err = htmlpdf.WriteHTMLAndPDFExp(htmlpdf.HTMLToPDFParams{
-		HTMLContent: introHTML,
-		HTMLPath:    introHtmlPath,
-		PDFPath:     filepath.Join(outputDir, "intro.pdf"),
-		Browser:     browser,
-		Description: "intro",
-	})
+	cfg.Layout = parsedLayout
+	parsedMetric, err := naming.ParseMetric(*matchMetric)
 	if err != nil {
-		log.Fatalf("[ERROR] Could not create intro: %v", err)
+		fmt.Fprintf(os.Stderr, "[ERROR] -match-metric: %v\n", err)
+		return 2
+	}
+	cfg.MatchMetric = parsedMetric
+	if *printTheme {
+		cfg.ThemeCSS = htmlpdf.PrintThemeCSS
+	}
+	if *themeFile != "" {
+		css, err := os.ReadFile(*themeFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] failed to read theme CSS: %v\n", err)
+			return 2
+		}
+		cfg.ThemeCSS += string(css)
+	}
+	if *prefaceFile != "" {
+		preface, err := os.ReadFile(*prefaceFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] failed to read preface: %v\n", err)
+			return 2
+		}
+		cfg.Preface = string(preface)
+	}
+	// Check the appendices now instead of after rendering the whole book
+	for _, appendix := range cfg.Appendices {
+		if _, err := os.Stat(appendix); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] -appendix: %v\n", err)
+			return 2
+		}
+	}
+	for _, lesson := range cfg.MarkdownFiles {
+		if _, err := os.Stat(lesson); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] -markdown: %v\n", err)
+			return 2
+		}
 	}
-	fmt.Printf("[INTRO PDF CREATED] intro.pdf\n")
-
-	// Clean up temporary files
-	htmlpdf.CleanupTmpFiles(outputDir, []string{"temp_intro.html", "temp_intro.pdf"})
 
-	// Now merge intro with examples
-	tempMergedPdf := filepath.Join(outputDir, "temp_with_intro.pdf")
-	introAndExamples := []string{filepath.Join(outputDir, "intro.pdf"), mergedExamplesPdf}
+	// Keep stdout free for the JSON result
+	var console io.Writer = os.Stdout
+	if *jsonResult {
+		console = os.Stderr
+		*quiet = true
+	}
 
-	err = api.MergeCreateFile(introAndExamples, tempMergedPdf, false, conf)
+	logger, closeLog, err := prepLogger(*quiet, *verbose, *logFile, console)
 	if err != nil {
-		log.Fatalf("[ERROR] Could not merge intro with examples: %v", err)
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		return 1
+	}
+	defer closeLog()
+	cfg.Logger = logger
+
+	// Report per-example progress on a single line instead of one log line
+	// per step. The bar redraws its line in place, so it goes to stderr where
+	// it cannot garble the log lines or a JSON result on stdout
+	cfg.Reporter = progress.NewBar(os.Stderr)
+	if *quiet || *logFile != "" {
+		cfg.Reporter = progress.Nop{}
 	}
 
-	// Add bookmarks to the final PDF
-	fmt.Println("[INFO] Adding bookmarks to PDF...")
+	ctx, stop := cancelOnInterrupt(logger)
+	defer stop()
+	cfg.Context = ctx
 
-	// Add bookmarks to the final PDF
-	finalPdf := "go-by-example-generated-ebook.pdf"
-	err = htmlpdf.ApplyBookmarks(htmlpdf.ApplyBookmarksParams{
-		TempMergedPDF:     tempMergedPdf,
-		FinalPDF:          finalPdf,
-		Examples:          examples,
-		IntroPageCount:    introPageCount,
-		ExamplePageCounts: examplePageCounts,
-	})
+	result, err := build.RunWithResult(cfg)
+	if *jsonResult {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			logger.Error("Could not write JSON result", "err", err)
+		}
+	}
 	if err != nil {
-		log.Fatalf("[ERROR] Could not apply bookmarks: %v", err)
+		// The book exists but is incomplete; scripts can tell this apart
+		// from a failed build by the exit code
+		if errors.Is(err, build.ErrInterrupted) {
+			logger.Warn("Build interrupted", "err", err)
+			return exitInterrupted
+		}
+		if errors.Is(err, build.ErrExamplesFailed) {
+			logger.Warn("Build finished with failures", "err", err)
+			return exitPartial
+		}
+		logger.Error("Build failed", "err", err)
+		if hint := failureHint(err); hint != "" {
+			logger.Warn(hint)
+		}
+		return 1
 	}
 
-	// Clean up temporary files
-	htmlpdf.CleanupTmpFiles(outputDir, []string{"merged_examples.pdf", "intro.pdf", "intro.html"})
-
-	fmt.Printf("[COMBINED PDF CREATED] %s\n", finalPdf)
-	fmt.Println("[SUCCESS] PDF generation completed!")
-	fmt.Printf("[INFO] Individual PDFs saved in: %s/\n", outputDir)
-	fmt.Printf("[INFO] Combined PDF saved as: %s\n", finalPdf)
-	fmt.Println("[INFO] Use the bookmarks panel in your PDF viewer for navigation!")
+	return 0
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"2024-01-31T12:30:00Z", time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC), false},
+		{"1000-01-01", time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"9999-12-31", time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"31.01.2024", time.Time{}, true},
+		{"0999-12-31", time.Time{}, true},
+		// In UTC this is already the year 10000
+		{"9999-12-31T23:00:00-05:00", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate("-date", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDate(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseBuildDateSourceDateEpoch(t *testing.T) {
+	tests := []struct {
+		epoch   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"1706704200", time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC), false},
+		{"", time.Time{}, false},
+		{"yesterday", time.Time{}, true},
+		{"-31000000000", time.Time{}, true},
+		{"253402300800", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Setenv(sourceDateEpochEnv, tt.epoch)
+		got, err := parseBuildDate("")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("$%s=%q: error = %v, want error %v", sourceDateEpochEnv, tt.epoch, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("$%s=%q: got %v, want %v", sourceDateEpochEnv, tt.epoch, got, tt.want)
+		}
+	}
+
+	// -date takes precedence
+	t.Setenv(sourceDateEpochEnv, "yesterday")
+	if got, err := parseBuildDate("2024-01-31"); err != nil || !got.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseBuildDate with -date = %v, %v", got, err)
+	}
+}